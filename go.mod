@@ -4,6 +4,8 @@ require (
 	github.com/NYTimes/gziphandler v1.1.1 // indirect
 	github.com/atomix/api v0.0.0-20200123231207-4e5fb1cbaf40
 	github.com/atomix/go-client v0.0.0-20200124004211-e5e19cd4730d
+	github.com/atomix/go-framework v0.0.0-20200124003840-f24758b13aa2
+	github.com/atomix/go-local v0.0.0-20200124003802-357f6682b2f4
 	github.com/docker/spdystream v0.0.0-20181023171402-6480d4af844c // indirect
 	github.com/elazarl/goproxy v0.0.0-20190703090003-6125c262ffb0 // indirect
 	github.com/emicklei/go-restful v2.9.6+incompatible // indirect
@@ -20,11 +22,13 @@ require (
 	github.com/hashicorp/hcl v1.0.0
 	github.com/inconshreveable/mousetrap v1.0.0
 	github.com/magiconair/properties v1.8.1
+	github.com/mattn/go-runewidth v0.0.3 // indirect
 	github.com/mailru/easyjson v0.0.0-20190626092158-b2ccc519800e // indirect
 	github.com/mitchellh/go-homedir v1.1.0
 	github.com/mitchellh/mapstructure v1.1.2
 	github.com/munnerz/goautoneg v0.0.0-20190414153302-2ae31c8b6b30 // indirect
 	github.com/pelletier/go-toml v1.4.0
+	github.com/peterh/liner v1.2.1
 	github.com/spf13/afero v1.2.2
 	github.com/spf13/cast v1.3.0
 	github.com/spf13/cobra v0.0.4
@@ -39,6 +43,7 @@ require (
 	gopkg.in/inf.v0 v0.9.1 // indirect
 	gopkg.in/yaml.v2 v2.2.2
 	k8s.io/apimachinery v0.0.0-20190703205208-4cfb76a8bf76
+	k8s.io/client-go v0.0.0-20190620085101-78d2af792bab
 	k8s.io/gengo v0.0.0-20190327210449-e17681d19d3a // indirect
 	k8s.io/klog v0.3.3 // indirect
 	k8s.io/kube-openapi v0.0.0-20190603182131-db7b694dc208 // indirect
@@ -15,15 +15,17 @@
 package cli
 
 import (
-	"fmt"
 	"github.com/atomix/cli/pkg/cli/command"
 	"os"
 )
 
 func Execute() {
 	rootCmd := command.GetRootCommand()
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	rootCmd.SetOutput(os.Stdout)
+	err := rootCmd.Execute()
+	command.Close()
+	if err != nil {
+		command.PrintError(os.Stderr, err)
+		os.Exit(command.GetExitCode(err))
 	}
 }
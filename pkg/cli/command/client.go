@@ -16,80 +16,209 @@ package command
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/atomix/go-client/pkg/client"
+	"github.com/atomix/go-client/pkg/client/primitive"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"net/url"
 	"strings"
 	"time"
 )
 
 const (
-	nameSep = "."
+	nameSep         = "."
+	pathSep         = "/"
+	primitiveScheme = "atomix"
 )
 
 func addClientFlags(cmd *cobra.Command) {
 	viper.SetDefault("group", "")
 	cmd.PersistentFlags().StringP("group", "g", viper.GetString("group"), fmt.Sprintf("the partition group name (default %s)", viper.GetString("group")))
 	cmd.PersistentFlags().Duration("timeout", 15*time.Second, "the operation timeout")
-	viper.BindPFlag("group", cmd.PersistentFlags().Lookup("group"))
-	cmd.PersistentFlags().Lookup("group").Annotations = map[string][]string{
-		cobra.BashCompCustom: {"__atomix_get_groups"},
-	}
+	bindConfigFlag("group", cmd.PersistentFlags().Lookup("group"))
+	cmd.PersistentFlags().SetAnnotation("group", cobra.BashCompCustom, []string{"__atomix_get_groups"})
 }
 
-func newTimeoutContext(cmd *cobra.Command) context.Context {
-	timeout, _ := cmd.Flags().GetDuration("timeout")
-	ctx, _ := context.WithTimeout(context.Background(), timeout)
-	return ctx
+// ClientFactory creates a client for the controller at the given address
+type ClientFactory func(address string, opts ...client.Option) (*client.Client, error)
+
+var clientFactory ClientFactory = client.NewClient
+
+// SetClientFactory sets the factory used by commands to connect to the controller
+func SetClientFactory(factory ClientFactory) {
+	clientFactory = factory
 }
 
-func newClientFromEnv() *client.Client {
-	c, err := client.NewClient(
-		getClientController(),
-		client.WithNamespace(getClientNamespace()),
-		client.WithApplication(getClientApp()))
-	if err != nil {
-		ExitWithError(ExitError, err)
+// getTimeout returns the operation timeout set by the --timeout flag or the configuration
+func getTimeout(cmd *cobra.Command) time.Duration {
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	if cmd.Flags().Changed("timeout") {
+		return timeout
 	}
-	return c
+	if value, err := time.ParseDuration(getConfig("timeout")); err == nil {
+		return value
+	}
+	return timeout
+}
+
+func newTimeoutContext(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(withCredentials(context.Background()), getTimeout(cmd))
+}
+
+// newClient creates a client for the configured controller
+func newClient(opts ...client.Option) (*client.Client, error) {
+	return newClientForController(getClientController(), opts...)
 }
 
-func newGroupFromEnv(cmd *cobra.Command) *client.PartitionGroup {
-	c := newClientFromEnv()
-	g, err := c.GetGroup(newTimeoutContext(cmd), getClientGroup())
+// newClientForController creates a client for the controller at the given address
+// The configured credentials are loaded and attached to requests made with contexts from newTimeoutContext.
+func newClientForController(controller string, opts ...client.Option) (*client.Client, error) {
+	if err := loadCredentials(); err != nil {
+		return nil, err
+	}
+	address, err := getControllerAddress(controller)
 	if err != nil {
-		ExitWithError(ExitError, err)
+		return nil, err
 	}
-	return g
+	return clientFactory(address, opts...)
 }
 
-func newClientFromGroup(name string) *client.Client {
-	c, err := client.NewClient(
-		getClientController(),
+func newClientFromEnv() (*client.Client, error) {
+	return newClient(
+		client.WithNamespace(getClientNamespace()),
+		client.WithApplication(getClientApp()))
+}
+
+func newGroupFromEnv(cmd *cobra.Command) (*client.PartitionGroup, error) {
+	key := getSharedGroupKey(getClientController(), getClientNamespace(), getClientGroup(), getClientApp())
+	return getSharedGroup(key, func() (*client.PartitionGroup, error) {
+		c, err := newClientFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		defer c.Close()
+		ctx, cancel := newTimeoutContext(cmd)
+		defer cancel()
+		return c.GetGroup(ctx, getClientGroup())
+	})
+}
+
+func newClientFromGroup(name string) (*client.Client, error) {
+	return newClient(
 		client.WithNamespace(getGroupNamespace(name)),
 		client.WithApplication(getClientApp()))
+}
+
+func newClientFromName(name *primitiveName) (*client.Client, error) {
+	return newClientForController(name.controller, client.WithNamespace(name.namespace), client.WithApplication(name.app))
+}
+
+func newGroupFromName(cmd *cobra.Command, name string) (*client.PartitionGroup, error) {
+	primitive, err := parsePrimitiveName(name)
 	if err != nil {
-		ExitWithError(ExitError, err)
+		return nil, err
 	}
-	return c
+	key := getSharedGroupKey(primitive.controller, primitive.namespace, primitive.group, primitive.app)
+	return getSharedGroup(key, func() (*client.PartitionGroup, error) {
+		c, err := newClientFromName(primitive)
+		if err != nil {
+			return nil, err
+		}
+		defer c.Close()
+		ctx, cancel := newTimeoutContext(cmd)
+		defer cancel()
+		return c.GetGroup(ctx, primitive.group)
+	})
 }
 
-func newClientFromName(name string) *client.Client {
-	c, err := client.NewClient(getClientController(), client.WithNamespace(getClientNamespace()), client.WithApplication(getPrimitiveApp(name)))
-	if err != nil {
-		ExitWithError(ExitError, err)
+// sharedGroups caches partition groups across the commands run by the shell and exec commands
+// If nil, each command resolves its partition group through the controller.
+var sharedGroups map[string]*client.PartitionGroup
+
+func getSharedGroupKey(controller, namespace, group, app string) string {
+	return strings.Join([]string{controller, namespace, group, app}, pathSep)
+}
+
+// getSharedGroup returns the shared partition group for the given key, creating it if necessary
+func getSharedGroup(key string, newGroup func() (*client.PartitionGroup, error)) (*client.PartitionGroup, error) {
+	if group, ok := sharedGroups[key]; ok {
+		return group, nil
 	}
-	return c
+	group, err := newGroup()
+	if err == nil && sharedGroups != nil {
+		sharedGroups[key] = group
+	}
+	return group, err
 }
 
-func newGroupFromName(cmd *cobra.Command, name string) *client.PartitionGroup {
-	c := newClientFromName(name)
-	group, err := c.GetGroup(newTimeoutContext(cmd), getClientGroup())
+// sharedPrimitives caches the primitives opened by the commands run by the shell and exec commands
+// Shared primitives keep their sessions open until closeSharedClients is called.
+var sharedPrimitives map[string]primitive.Primitive
+
+// shareClients shares partition groups and primitives across the commands run until closeSharedClients is called
+// Runs cannot be nested, since the inner run would close the primitives shared by the outer run.
+func shareClients() error {
+	if sharedPrimitives != nil {
+		return newExitError(ExitInvalidInput, errors.New("shell and exec cannot be run by the shell or exec commands"))
+	}
+	sharedGroups = make(map[string]*client.PartitionGroup)
+	sharedPrimitives = make(map[string]primitive.Primitive)
+	return nil
+}
+
+// closeSharedClients closes the shared primitives and stops sharing partition groups and primitives
+func closeSharedClients() {
+	for _, p := range sharedPrimitives {
+		p.Close()
+	}
+	sharedGroups = nil
+	sharedPrimitives = nil
+}
+
+// newPrimitiveFromName returns the primitive of the given type with the given name, creating it if it's not shared
+func newPrimitiveFromName(cmd *cobra.Command, t primitive.Type, name string, newPrimitive func(context.Context, *client.PartitionGroup, string) (primitive.Primitive, error)) (primitive.Primitive, error) {
+	parsed, err := parsePrimitiveName(name)
+	if err != nil {
+		return nil, err
+	}
+	key := strings.Join([]string{string(t), parsed.controller, parsed.namespace, parsed.group, parsed.app, parsed.name}, pathSep)
+	if p, ok := sharedPrimitives[key]; ok {
+		return p, nil
+	}
+	group, err := newGroupFromName(cmd, name)
 	if err != nil {
-		ExitWithError(ExitError, err)
+		return nil, err
+	}
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	p, err := newPrimitive(ctx, group, parsed.name)
+	if err != nil {
+		return nil, err
+	}
+	if sharedPrimitives != nil {
+		sharedPrimitives[key] = p
+	}
+	return p, nil
+}
+
+// closePrimitive closes the given primitive unless it's shared with the other commands run by the shell or exec command
+func closePrimitive(p primitive.Primitive) error {
+	if sharedPrimitives != nil {
+		return nil
+	}
+	return p.Close()
+}
+
+// deletePrimitive deletes the given primitive and removes it from the shared primitives
+func deletePrimitive(p primitive.Primitive) error {
+	for key, shared := range sharedPrimitives {
+		if shared == p {
+			delete(sharedPrimitives, key)
+		}
 	}
-	return group
+	return p.Delete()
 }
 
 func splitName(name string) []string {
@@ -133,15 +262,68 @@ func getClientApp() string {
 	return getConfig("app")
 }
 
-func getPrimitiveApp(name string) string {
+// primitiveName is a fully qualified primitive name
+type primitiveName struct {
+	controller string
+	namespace  string
+	group      string
+	app        string
+	name       string
+}
+
+// parsePrimitiveName parses a primitive name
+// Names may be given as a URI of the form atomix://controller/namespace/group/app/name, a path of the
+// form group/app/name, or a name of the form [app.]name. The controller may be omitted from a URI
+// (atomix:///namespace/group/app/name), and parts that are not given default to the configuration.
+func parsePrimitiveName(name string) (*primitiveName, error) {
+	primitive := &primitiveName{
+		controller: getClientController(),
+		namespace:  getClientNamespace(),
+		group:      getClientGroup(),
+		app:        getClientApp(),
+	}
+
+	if strings.HasPrefix(name, primitiveScheme+"://") {
+		uri, err := url.Parse(name)
+		if err != nil {
+			return nil, newExitError(ExitInvalidInput, fmt.Errorf("invalid primitive URI %s: %s", name, err))
+		}
+		parts := strings.Split(strings.TrimPrefix(uri.Path, "/"), "/")
+		if len(parts) != 4 || hasEmptyPart(parts) {
+			return nil, newExitError(ExitInvalidInput, fmt.Errorf("invalid primitive URI %s: expected %s://controller/namespace/group/app/name", name, primitiveScheme))
+		}
+		if uri.Host != "" {
+			primitive.controller = uri.Host
+		}
+		primitive.namespace, primitive.group, primitive.app, primitive.name = parts[0], parts[1], parts[2], parts[3]
+		return primitive, nil
+	}
+
+	if strings.Contains(name, pathSep) {
+		parts := strings.Split(name, pathSep)
+		if len(parts) != 3 || hasEmptyPart(parts) {
+			return nil, newExitError(ExitInvalidInput, fmt.Errorf("invalid primitive name %s: expected group/app/name", name))
+		}
+		primitive.group, primitive.app, primitive.name = parts[0], parts[1], parts[2]
+		return primitive, nil
+	}
+
 	nameParts := splitName(name)
+	if len(nameParts) > 2 || hasEmptyPart(nameParts) {
+		return nil, newExitError(ExitInvalidInput, fmt.Errorf("invalid primitive name %s: expected [app.]name, or group/app/name to qualify the name further", name))
+	}
 	if len(nameParts) == 2 {
-		return nameParts[0]
+		primitive.app = nameParts[0]
 	}
-	return getClientApp()
+	primitive.name = nameParts[len(nameParts)-1]
+	return primitive, nil
 }
 
-func getPrimitiveName(name string) string {
-	nameParts := splitName(name)
-	return nameParts[len(nameParts)-1]
+func hasEmptyPart(parts []string) bool {
+	for _, part := range parts {
+		if part == "" {
+			return true
+		}
+	}
+	return false
 }
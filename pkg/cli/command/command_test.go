@@ -0,0 +1,296 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/mitchellh/go-homedir"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// Run commands against an empty home directory so the user's configuration is not read or written
+	home, err := ioutil.TempDir("", "atomix-test-")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("HOME", home)
+	homedir.DisableCache = true
+	for _, env := range os.Environ() {
+		if strings.HasPrefix(env, envPrefix+"_") {
+			os.Unsetenv(strings.SplitN(env, "=", 2)[0])
+		}
+	}
+	code := m.Run()
+	Close()
+	os.RemoveAll(home)
+	os.Exit(code)
+}
+
+// setTestHome points HOME at an empty directory for the duration of the test, so
+// configuration written by one test is not read by another
+func setTestHome(t *testing.T) {
+	home, err := ioutil.TempDir("", "atomix-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := os.Getenv("HOME")
+	os.Setenv("HOME", home)
+	t.Cleanup(func() {
+		os.Setenv("HOME", previous)
+		os.RemoveAll(home)
+	})
+}
+
+// setTestProject runs the test in a project directory containing a configuration file with the given contents
+// The directory is returned and removed when the test completes.
+func setTestProject(t *testing.T, config string) string {
+	project, err := ioutil.TempDir("", "atomix-project-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(project, projectConfigFile), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(project); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.Chdir(wd)
+		os.RemoveAll(project)
+	})
+	return project
+}
+
+// executeCommand runs the given command line in-process, returning the command output and error output
+func executeCommand(args ...string) (string, string, error) {
+	out := &bytes.Buffer{}
+	errOut := &bytes.Buffer{}
+	SetErrorOutput(errOut)
+	defer SetErrorOutput(os.Stderr)
+
+	cmd := GetRootCommand()
+	cmd.SetOutput(out)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), errOut.String(), err
+}
+
+// getTestExitCode returns the exit code for the given command error
+func getTestExitCode(err error) int {
+	if err == nil {
+		return ExitSuccess
+	}
+	return GetExitCode(err)
+}
+
+func TestCommandErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{
+			name: "unknown flag",
+			args: []string{"map", "get", "--name", "m", "--bogus"},
+			code: ExitBadArgs,
+		},
+		{
+			name: "unknown context",
+			args: []string{"groups", "--context", "missing"},
+			code: ExitInvalidInput,
+		},
+		{
+			name: "unknown configuration key",
+			args: []string{"config", "get", "bogus"},
+			code: ExitInvalidInput,
+		},
+		{
+			name: "invalid configuration value",
+			args: []string{"config", "set", "retries", "many"},
+			code: ExitInvalidInput,
+		},
+		{
+			name: "invalid context name",
+			args: []string{"config", "set-context", "Prod"},
+			code: ExitInvalidInput,
+		},
+		{
+			name: "invalid primitive name",
+			args: []string{"map", "get", "--name", "a/b", "--key", "k"},
+			code: ExitInvalidInput,
+		},
+		{
+			name: "invalid output format",
+			args: []string{"map", "put", "--name", "m", "--key", "k", "--value", "v", "--controller", "localhost:1", "-o", "bogus"},
+			code: ExitInvalidInput,
+		},
+		{
+			name: "invalid output template",
+			args: []string{"map", "get", "--name", "m", "--key", "k", "--controller", "localhost:1", "-o", "go-template={{"},
+			code: ExitInvalidInput,
+		},
+		{
+			name: "invalid dotted primitive name",
+			args: []string{"map", "get", "--name", "a.b.c", "--key", "k", "--controller", "localhost:1"},
+			code: ExitInvalidInput,
+		},
+		{
+			name: "missing value",
+			args: []string{"map", "put", "--name", "m", "--key", "k"},
+			code: ExitInvalidInput,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := executeCommand(test.args...)
+			if code := getTestExitCode(err); code != test.code {
+				t.Errorf("expected exit code %d, got %d (%v)", test.code, code, err)
+			}
+		})
+	}
+}
+
+func TestConfigCommands(t *testing.T) {
+	setTestHome(t)
+	tests := []struct {
+		name   string
+		args   []string
+		output string
+	}{
+		{
+			name:   "flag overrides default",
+			args:   []string{"config", "get", "controller", "--controller", "localhost:1234"},
+			output: "localhost:1234\n",
+		},
+		{
+			name:   "set value",
+			args:   []string{"config", "set", "app", "test-app"},
+			output: "test-app\n",
+		},
+		{
+			name:   "value is read from the file",
+			args:   []string{"config", "get", "app"},
+			output: "test-app\n",
+		},
+		{
+			name:   "flags are not persisted",
+			args:   []string{"config", "get", "controller"},
+			output: ":5679\n",
+		},
+		{
+			name:   "JSON output",
+			args:   []string{"config", "get", "app", "-o", "json"},
+			output: "\"test-app\"\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, _, err := executeCommand(test.args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != test.output {
+				t.Errorf("expected output %q, got %q", test.output, output)
+			}
+		})
+	}
+}
+
+func TestInitCommand(t *testing.T) {
+	setTestHome(t)
+	home := os.Getenv("HOME")
+
+	// A project configuration file in the working directory must be neither overwritten nor copied by init
+	projectConfig := "app: project\ncontroller: project:1\ntoken-command: echo project\n"
+	project := setTestProject(t, projectConfig)
+	projectFile := filepath.Join(project, projectConfigFile)
+
+	homeFile := filepath.Join(home, ".atomix", "config.yaml")
+	explicitFile := filepath.Join(project, "explicit.yaml")
+	tests := []struct {
+		name    string
+		args    []string
+		file    string
+		content string
+		code    int
+	}{
+		{
+			name:    "home file",
+			args:    []string{"init"},
+			file:    homeFile,
+			content: "app: default\ncontroller: :5679\ngroup: \"\"\nnamespace: default\n",
+		},
+		{
+			name: "existing file",
+			args: []string{"init"},
+			code: ExitInvalidInput,
+		},
+		{
+			name:    "explicit file",
+			args:    []string{"init", "--config", explicitFile, "--controller", "localhost:1234"},
+			file:    explicitFile,
+			content: "app: default\ncontroller: localhost:1234\ngroup: \"\"\nnamespace: default\n",
+		},
+		{
+			name: "set context",
+			args: []string{"config", "set-context", "test", "--config", explicitFile, "--app", "test-app"},
+		},
+		{
+			name:    "force overwrites file",
+			args:    []string{"init", "--force", "--config", explicitFile},
+			file:    explicitFile,
+			content: "app: default\ncontroller: localhost:1234\ngroup: \"\"\nnamespace: default\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, _, err := executeCommand(test.args...)
+			if code := getTestExitCode(err); code != test.code {
+				t.Fatalf("expected exit code %d, got %d (%v)", test.code, code, err)
+			}
+			if test.file == "" {
+				return
+			}
+			if expected := fmt.Sprintf("Created %s\n", test.file); output != expected {
+				t.Errorf("expected output %q, got %q", expected, output)
+			}
+			data, err := ioutil.ReadFile(test.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != test.content {
+				t.Errorf("expected %s to contain %q, got %q", test.file, test.content, data)
+			}
+		})
+	}
+
+	data, err := ioutil.ReadFile(projectFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != projectConfig {
+		t.Errorf("project configuration was modified: %q", data)
+	}
+}
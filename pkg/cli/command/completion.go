@@ -19,12 +19,11 @@ import (
 	"errors"
 	"github.com/spf13/cobra"
 	"io"
-	"os"
 )
 
 const bashCompletion = `
 
-__atomix_override_flag_list=(--controller --namespace --app --group -g)
+__atomix_override_flag_list=(--context --controller --namespace --app --group -g)
 __atomix_override_flags()
 {
     local ${__atomix_override_flag_list[*]##*-} two_word_of of var
@@ -60,6 +59,14 @@ __atomix_get_groups() {
     fi
 }
 
+__atomix_get_contexts() {
+    local atomix_output out
+    if atomix_output=$(atomix config get-contexts --no-headers -o 'jsonpath={[*].name}' 2>/dev/null); then
+        out=(${atomix_output})
+        COMPREPLY=( $( compgen -W "${out[*]}" -- "$cur" ) )
+    fi
+}
+
 __atomix_primitive_types() {
     echo "counter"
     echo "election"
@@ -187,22 +194,17 @@ func newCompletionCommand() *cobra.Command {
 		Use:       "completion <shell>",
 		Args:      cobra.ExactArgs(1),
 		ValidArgs: []string{"bash", "zsh"},
-		Run:       runCompletionCommand,
+		RunE:      runCompletionCommand,
 	}
 }
 
-func runCompletionCommand(cmd *cobra.Command, args []string) {
+func runCompletionCommand(cmd *cobra.Command, args []string) error {
 	if args[0] == "bash" {
-		if err := runCompletionBash(os.Stdout, cmd.Parent()); err != nil {
-			ExitWithError(ExitError, err)
-		}
+		return runCompletionBash(cmd.OutOrStdout(), cmd.Parent())
 	} else if args[0] == "zsh" {
-		if err := runCompletionZsh(os.Stdout, cmd.Parent()); err != nil {
-			ExitWithError(ExitError, err)
-		}
-	} else {
-		ExitWithError(ExitError, errors.New("unsupported shell type "+args[0]))
+		return runCompletionZsh(cmd.OutOrStdout(), cmd.Parent())
 	}
+	return newExitError(ExitInvalidInput, errors.New("unsupported shell type "+args[0]))
 }
 
 func runCompletionBash(out io.Writer, cmd *cobra.Command) error {
@@ -15,13 +15,209 @@
 package command
 
 import (
+	"fmt"
 	"github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
 )
 
+const (
+	configFileEnv     = "ATOMIX_CONFIG"
+	projectConfigFile = ".atomix.yaml"
+)
+
+const (
+	sourceFlag    = "flag"
+	sourceEnv     = "env"
+	sourceFile    = "file"
+	sourceContext = "context"
+	sourceDefault = "default"
+)
+
+// redactedValue is displayed in place of secret configuration values
+const redactedValue = "<redacted>"
+
+// configKeyAnnotation is the flag annotation holding the configuration key the flag overrides
+const configKeyAnnotation = "atomix_config_key"
+
+const (
+	envPrefix  = "ATOMIX"
+	contextEnv = "ATOMIX_CONTEXT"
+)
+
+// envKeyReplacer maps configuration keys to environment variable names
+var envKeyReplacer = strings.NewReplacer("-", "_", ".", "_")
+
+// configKey is a documented configuration key
+type configKey struct {
+	name        string
+	description string
+	validate    func(value string) error
+	secret      bool
+}
+
+// configKeys is the list of documented configuration keys
+var configKeys = []configKey{
+	{
+		name:        "controller",
+		description: "the controller address",
+	},
+	{
+		name:        "namespace",
+		description: "the partition group namespace",
+	},
+	{
+		name:        "group",
+		description: "the default partition group",
+	},
+	{
+		name:        "app",
+		description: "the application name",
+	},
+	{
+		name:        tlsKey,
+		description: "whether to connect to the controller using TLS",
+		validate:    validateBool,
+	},
+	{
+		name:        tlsCACertKey,
+		description: "the CA certificate used to verify the controller",
+	},
+	{
+		name:        tlsCertKey,
+		description: "the client certificate used for mutual TLS",
+	},
+	{
+		name:        tlsKeyKey,
+		description: "the client key used for mutual TLS",
+	},
+	{
+		name:        tlsInsecureSkipVerify,
+		description: "whether to skip verification of the controller certificate",
+		validate:    validateBool,
+	},
+	{
+		name:        tokenKey,
+		description: "the bearer token used to authenticate requests",
+		secret:      true,
+	},
+	{
+		name:        tokenFileKey,
+		description: "a file containing the bearer token used to authenticate requests",
+	},
+	{
+		name:        tokenCommandKey,
+		description: "a credential plugin command that prints a bearer token",
+	},
+	{
+		name:        retriesKey,
+		description: "the number of times to retry reads when the controller is unavailable",
+		validate: func(value string) error {
+			_, err := strconv.Atoi(value)
+			return err
+		},
+	},
+	{
+		name:        waitKey,
+		description: "whether to wait until the controller is reachable before running commands",
+		validate:    validateBool,
+	},
+	{
+		name:        "timeout",
+		description: "the operation timeout",
+		validate: func(value string) error {
+			_, err := time.ParseDuration(value)
+			return err
+		},
+	},
+	{
+		name:        "error-format",
+		description: "the error output format (text, json)",
+		validate: func(value string) error {
+			if value != errorFormatText && value != errorFormatJSON {
+				return fmt.Errorf("unsupported error format %s", value)
+			}
+			return nil
+		},
+	},
+}
+
+func validateBool(value string) error {
+	_, err := strconv.ParseBool(value)
+	return err
+}
+
+// getConfigKeyNames returns the names of the documented configuration keys
+func getConfigKeyNames() []string {
+	names := make([]string, len(configKeys))
+	for i, key := range configKeys {
+		names[i] = key.name
+	}
+	return names
+}
+
+// getConfigKey returns the documented configuration key with the given name
+func getConfigKey(name string) (configKey, error) {
+	for _, key := range configKeys {
+		if key.name == name {
+			return key, nil
+		}
+	}
+	return configKey{}, newExitError(ExitInvalidInput, fmt.Errorf("unknown configuration key %s; valid keys are %s", name, strings.Join(getConfigKeyNames(), ", ")))
+}
+
+// getConfigEnv returns the name of the environment variable that overrides the given key
+func getConfigEnv(key string) string {
+	if key == currentContextKey {
+		return contextEnv
+	}
+	return fmt.Sprintf("%s_%s", envPrefix, strings.ToUpper(envKeyReplacer.Replace(key)))
+}
+
+func isEnvSet(key string) bool {
+	return os.Getenv(getConfigEnv(key)) != ""
+}
+
+// getConfigHelp returns the help text describing the configuration keys and their precedence
+func getConfigHelp() string {
+	var help strings.Builder
+	help.WriteString("Read and update CLI configuration options\n\n")
+	help.WriteString("Values are resolved from flags, then environment variables, then the current context,\n")
+	help.WriteString("then the top level of the configuration file, and finally the built-in defaults.\n\n")
+	help.WriteString("Configuration keys:\n")
+	writer := tabwriter.NewWriter(&help, 0, 0, 3, ' ', 0)
+	for _, key := range configKeys {
+		fmt.Fprintf(writer, "  %s\t%s\t%s\n", key.name, getConfigEnv(key.name), key.description)
+	}
+	fmt.Fprintf(writer, "  %s\t%s\t%s\n", "context", contextEnv, "the configuration context")
+	writer.Flush()
+	return help.String()
+}
+
 var (
 	configFile = ""
+
+	// configTrusted indicates whether the configuration file was chosen by the user rather than discovered
+	// in the working directory, and so may run commands
+	configTrusted bool
+
+	// configErr holds any error that occurred while parsing the configuration file
+	configErr error
+
+	// configUpdates holds the top level keys changed by the command, mapped to whether the key is still set
+	configUpdates = make(map[string]bool)
+
+	// commandFlags holds the flags of the command being executed, used to determine which
+	// configuration values were overridden on the command line
+	commandFlags *pflag.FlagSet
 )
 
 func init() {
@@ -30,108 +226,356 @@ func init() {
 
 func newConfigCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "config {set,get,delete} [args]",
+		Use:   "config {view,set,get,delete,get-contexts,use-context,set-context,delete-context} [args]",
 		Short: "Read and update CLI configuration options",
+		Long:  getConfigHelp(),
 	}
+	cmd.AddCommand(newConfigViewCommand())
 	cmd.AddCommand(newConfigGetCommand())
 	cmd.AddCommand(newConfigSetCommand())
 	cmd.AddCommand(newConfigDeleteCommand())
+	cmd.AddCommand(newConfigGetContextsCommand())
+	cmd.AddCommand(newConfigUseContextCommand())
+	cmd.AddCommand(newConfigSetContextCommand())
+	cmd.AddCommand(newConfigDeleteContextCommand())
 	return cmd
 }
 
-func newConfigGetCommand() *cobra.Command {
-	validArgs := []string{
-		"controller",
-		"namespace",
-		"group",
-		"app",
+func newConfigViewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "view",
+		Short: "Show the effective configuration and where each value comes from",
+		Args:  cobra.NoArgs,
+		RunE:  runConfigViewCommand,
+	}
+	cmd.Flags().Bool("no-headers", false, "exclude headers from the output")
+	return cmd
+}
+
+func runConfigViewCommand(cmd *cobra.Command, _ []string) error {
+	settings := configSettingList{
+		{
+			Key:    "context",
+			Value:  getCurrentContext(),
+			Source: getConfigSource(currentContextKey),
+		},
+	}
+	for _, key := range configKeys {
+		value := getConfig(key.name)
+		if key.secret && value != "" {
+			value = redactedValue
+		}
+		settings = append(settings, &configSetting{
+			Key:    key.name,
+			Value:  value,
+			Source: getConfigSource(key.name),
+		})
+	}
+	return printResult(cmd, settings)
+}
+
+// configSetting is the output representation of an effective configuration value
+type configSetting struct {
+	Key    string `json:"key" yaml:"key"`
+	Value  string `json:"value" yaml:"value"`
+	Source string `json:"source" yaml:"source"`
+}
+
+// configSettingList is the output representation of a list of configuration values
+type configSettingList []*configSetting
+
+func (l configSettingList) writeTable(out io.Writer, includeHeaders bool) {
+	writer := new(tabwriter.Writer)
+	writer.Init(out, 0, 0, 3, ' ', tabwriter.FilterHTML)
+	if includeHeaders {
+		fmt.Fprintln(writer, "KEY\tVALUE\tSOURCE")
 	}
+	for _, setting := range l {
+		fmt.Fprintln(writer, fmt.Sprintf("%s\t%s\t%s", setting.Key, setting.Value, setting.Source))
+	}
+	writer.Flush()
+}
+
+func newConfigGetCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:       "get <key>",
 		Args:      cobra.ExactArgs(1),
-		ValidArgs: validArgs,
-		Run:       runConfigGetCommand,
+		ValidArgs: getConfigKeyNames(),
+		RunE:      runConfigGetCommand,
 	}
 }
 
-func runConfigGetCommand(cmd *cobra.Command, args []string) {
-	value := viper.Get(args[0])
-	ExitWithOutput(value)
+func runConfigGetCommand(cmd *cobra.Command, args []string) error {
+	if _, err := getConfigKey(args[0]); err != nil {
+		return err
+	}
+	return printResult(cmd, getConfig(args[0]))
 }
 
 func newConfigSetCommand() *cobra.Command {
-	validArgs := []string{
-		"controller",
-		"namespace",
-		"group",
-		"app",
-	}
 	return &cobra.Command{
 		Use:       "set <key> <value>",
 		Args:      cobra.ExactArgs(2),
-		ValidArgs: validArgs,
-		Run:       runConfigSetCommand,
+		ValidArgs: getConfigKeyNames(),
+		RunE:      runConfigSetCommand,
 	}
 }
 
-func runConfigSetCommand(cmd *cobra.Command, args []string) {
-	viper.Set(args[0], args[1])
-	if err := viper.WriteConfig(); err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		value := viper.Get(args[0])
-		ExitWithOutput(value)
+func runConfigSetCommand(cmd *cobra.Command, args []string) error {
+	key, err := getConfigKey(args[0])
+	if err != nil {
+		return err
+	}
+	if key.validate != nil {
+		if err := key.validate(args[1]); err != nil {
+			return newExitError(ExitInvalidInput, fmt.Errorf("invalid value for %s: %s", key.name, err))
+		}
+	}
+	if err := setConfig(args[0], args[1]); err != nil {
+		return err
 	}
+	return printResult(cmd, getConfig(args[0]))
 }
 
 func newConfigDeleteCommand() *cobra.Command {
-	validArgs := []string{
-		"controller",
-		"namespace",
-		"group",
-		"app",
-	}
 	return &cobra.Command{
 		Use:       "delete <key>",
 		Args:      cobra.ExactArgs(1),
-		ValidArgs: validArgs,
-		Run:       runConfigDeleteCommand,
+		ValidArgs: getConfigKeyNames(),
+		RunE:      runConfigDeleteCommand,
 	}
 }
 
-func runConfigDeleteCommand(cmd *cobra.Command, args []string) {
-	viper.Set(args[0], nil)
-	if err := viper.WriteConfig(); err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		value := viper.Get(args[0])
-		ExitWithOutput(value)
+func runConfigDeleteCommand(cmd *cobra.Command, args []string) error {
+	if _, err := getConfigKey(args[0]); err != nil {
+		return err
 	}
+	if err := deleteConfig(args[0]); err != nil {
+		return err
+	}
+	return printResult(cmd, getConfig(args[0]))
 }
 
+// setConfig sets the given key in the current context, or at the top level if no context is in use
 func setConfig(key string, value string) error {
-	viper.Set(key, value)
-	return viper.WriteConfig()
+	if context := getCurrentContext(); context != "" {
+		updateConfig(getContextKey(context, key), value)
+	} else {
+		updateConfig(key, value)
+	}
+	return writeConfig()
 }
 
+// deleteConfig deletes the given key from the current context, or from the top level if no context is in use
+func deleteConfig(key string) error {
+	if context := getCurrentContext(); context != "" {
+		settings := viper.GetStringMap(getContextKey(context, ""))
+		delete(settings, key)
+		updateConfig(getContextKey(context, ""), settings)
+	} else {
+		updateConfig(key, nil)
+	}
+	return writeConfig()
+}
+
+// getConfig returns the value of the given key
+// Values set by flags take precedence over ATOMIX_ environment variables, which take precedence over values
+// set in the current context, which take precedence over top level values in the configuration file.
 func getConfig(key string) string {
+	if flag := getConfigFlag(key); flag != nil {
+		return flag.Value.String()
+	}
+	if context := getCurrentContext(); context != "" && !isEnvSet(key) {
+		if contextKey := getContextKey(context, key); viper.IsSet(contextKey) {
+			return viper.GetString(contextKey)
+		}
+	}
 	return viper.GetString(key)
 }
 
-func initConfig() {
+// getConfigSource describes where the effective value of the given key comes from
+func getConfigSource(key string) string {
+	if flag := getConfigFlag(key); flag != nil {
+		return fmt.Sprintf("%s --%s", sourceFlag, flag.Name)
+	}
+	if isEnvSet(key) {
+		return fmt.Sprintf("%s %s", sourceEnv, getConfigEnv(key))
+	}
+	if context := getCurrentContext(); context != "" && key != currentContextKey {
+		if viper.IsSet(getContextKey(context, key)) {
+			return fmt.Sprintf("%s %s (%s)", sourceContext, context, viper.ConfigFileUsed())
+		}
+	}
+	if viper.InConfig(key) {
+		return fmt.Sprintf("%s %s", sourceFile, viper.ConfigFileUsed())
+	}
+	return sourceDefault
+}
+
+// bindConfigFlag binds the given flag to a configuration key
+// The same key may be bound to flags on several commands; only the flags of the executing command are used.
+// The key is recorded in the flag's annotations so it's released along with the command tree.
+func bindConfigFlag(key string, flag *pflag.Flag) {
+	viper.BindPFlag(key, flag)
+	if flag.Annotations == nil {
+		flag.Annotations = make(map[string][]string)
+	}
+	flag.Annotations[configKeyAnnotation] = []string{key}
+}
+
+// getConfigFlag returns the flag overriding the given key if it was set on the command line
+func getConfigFlag(key string) *pflag.Flag {
+	var result *pflag.Flag
+	if commandFlags != nil {
+		commandFlags.Visit(func(flag *pflag.Flag) {
+			if keys := flag.Annotations[configKeyAnnotation]; len(keys) == 1 && keys[0] == key {
+				result = flag
+			}
+		})
+	}
+	return result
+}
+
+// updateConfig sets the given key and marks it to be written to the configuration file
+func updateConfig(key string, value interface{}) {
+	viper.Set(key, value)
+	root := strings.SplitN(key, ".", 2)[0]
+	configUpdates[root] = value != nil || root != key
+}
+
+// getConfigFile returns the path to the configuration file in use, or the default path if no file has been read
+func getConfigFile() (string, error) {
+	if path := viper.ConfigFileUsed(); path != "" {
+		return path, nil
+	}
+	return getDefaultConfigFile()
+}
+
+// getExplicitConfigFile returns the configuration file selected by the --config flag or the ATOMIX_CONFIG
+// environment variable, or an empty string if neither is set
+func getExplicitConfigFile() string {
 	if configFile != "" {
-		viper.SetConfigFile(configFile)
-	} else {
-		home, err := homedir.Dir()
-		if err != nil {
-			ExitWithError(ExitError, err)
+		return configFile
+	}
+	return os.Getenv(configFileEnv)
+}
+
+// getDefaultConfigFile returns the path to the configuration file in the user's home directory
+func getDefaultConfigFile() (string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".atomix", "config.yaml"), nil
+}
+
+// writeConfig writes the configuration to the file in use, creating the default configuration file if necessary
+func writeConfig() error {
+	path, err := getConfigFile()
+	if err != nil {
+		return err
+	}
+	return writeConfigAs(path)
+}
+
+// writeConfigAs writes the configuration to the given path, creating the parent directory if necessary
+// Only values read from the configuration file or updated by the command are written; defaults, flags and
+// environment variables are not persisted.
+func writeConfigAs(path string) error {
+	current := viper.New()
+	if used := viper.ConfigFileUsed(); used != "" {
+		current.SetConfigFile(used)
+		current.ReadInConfig()
+	}
+
+	config := viper.New()
+	for key, value := range current.AllSettings() {
+		if _, ok := configUpdates[key]; !ok {
+			config.Set(key, value)
+		}
+	}
+	settings := viper.AllSettings()
+	for key, set := range configUpdates {
+		if set {
+			config.Set(key, settings[key])
+		}
+	}
+	if err := writeConfigFile(config, path); err != nil {
+		return err
+	}
+	viper.SetConfigFile(path)
+	return nil
+}
+
+// writeConfigFile writes the given configuration to the given path, creating the parent directory if necessary
+// The file may contain tokens, so it is only readable by the user.
+func writeConfigFile(config *viper.Viper, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	file.Close()
+	if err := os.Chmod(path, 0600); err != nil {
+		return err
+	}
+	return config.WriteConfigAs(path)
+}
+
+// findProjectConfig searches the working directory and its parents for a project configuration file
+func findProjectConfig() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, projectConfigFile)
+		if _, err := os.Stat(path); err == nil {
+			return path
 		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// initConfig enables ATOMIX_ environment variables and reads the configuration file
+// The file is selected by the --config flag, the ATOMIX_CONFIG environment variable, or the nearest
+// .atomix.yaml in the working directory or its parents, in that order. If none is found, the default
+// locations are searched.
+func initConfig() {
+	viper.SetEnvPrefix(envPrefix)
+	viper.SetEnvKeyReplacer(envKeyReplacer)
+	viper.AutomaticEnv()
+	viper.BindEnv(currentContextKey, contextEnv)
+
+	path := getExplicitConfigFile()
+	explicit := path != ""
+	if path == "" {
+		path = findProjectConfig()
+	}
 
+	if path != "" {
+		viper.SetConfigFile(path)
+	} else {
 		viper.SetConfigName("config")
-		viper.AddConfigPath(home + "/.atomix")
+		if home, err := homedir.Dir(); err == nil {
+			viper.AddConfigPath(home + "/.atomix")
+		}
 		viper.AddConfigPath("/etc/atomix")
 		viper.AddConfigPath(".")
 	}
 
-	viper.ReadInConfig()
+	if err := viper.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigParseError); ok {
+			configErr = err
+		}
+	}
+
+	defaultPath, _ := getDefaultConfigFile()
+	configTrusted = explicit || viper.ConfigFileUsed() == defaultPath
 }
@@ -0,0 +1,269 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"io"
+	"regexp"
+	"sort"
+	"text/tabwriter"
+)
+
+const (
+	contextsKey       = "contexts"
+	currentContextKey = "current-context"
+)
+
+// contextNamePattern matches valid context names
+// Contexts are stored as nested configuration keys, which are case insensitive and separated by dots.
+var contextNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// contextKeys is the list of configuration keys that can be set per context
+var contextKeys = []string{
+	"controller",
+	"namespace",
+	"group",
+	"app",
+	tlsKey,
+	tlsCACertKey,
+	tlsCertKey,
+	tlsKeyKey,
+	tlsInsecureSkipVerify,
+	tokenKey,
+	tokenFileKey,
+	tokenCommandKey,
+}
+
+func addContextFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().String("context", "", "the configuration context to use")
+	bindConfigFlag(currentContextKey, cmd.PersistentFlags().Lookup("context"))
+	cmd.PersistentFlags().SetAnnotation("context", cobra.BashCompCustom, []string{"__atomix_get_contexts"})
+}
+
+// contextOverride is the context selected by withContext, overriding the current context
+var contextOverride string
+
+// withContext runs the given function with the named context in place of the current context
+func withContext(name string, f func() error) error {
+	if !hasContext(name) {
+		return newExitError(ExitInvalidInput, fmt.Errorf("unknown context %s", name))
+	}
+	previous := contextOverride
+	contextOverride = name
+	defer func() {
+		contextOverride = previous
+	}()
+	return f()
+}
+
+// getCurrentContext returns the name of the context selected by the --context flag or the configuration file
+func getCurrentContext() string {
+	if contextOverride != "" {
+		return contextOverride
+	}
+	if flag := getConfigFlag(currentContextKey); flag != nil {
+		return flag.Value.String()
+	}
+	return viper.GetString(currentContextKey)
+}
+
+// getContexts returns the contexts defined in the configuration file
+func getContexts() map[string]map[string]interface{} {
+	contexts := make(map[string]map[string]interface{})
+	for name := range viper.GetStringMap(contextsKey) {
+		contexts[name] = viper.GetStringMap(getContextKey(name, ""))
+	}
+	return contexts
+}
+
+func getContextKey(context string, key string) string {
+	if key == "" {
+		return fmt.Sprintf("%s.%s", contextsKey, context)
+	}
+	return fmt.Sprintf("%s.%s.%s", contextsKey, context, key)
+}
+
+func hasContext(name string) bool {
+	_, ok := getContexts()[name]
+	return ok
+}
+
+// validateContext verifies that the selected context exists
+func validateContext() error {
+	if name := getCurrentContext(); name != "" && !hasContext(name) {
+		return newExitError(ExitInvalidInput, fmt.Errorf("unknown context %s", name))
+	}
+	return nil
+}
+
+func newConfigGetContextsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "get-contexts",
+		Short: "List the configuration contexts",
+		Args:  cobra.NoArgs,
+		RunE:  runConfigGetContextsCommand,
+	}
+	cmd.Flags().Bool("no-headers", false, "exclude headers from the output")
+	return cmd
+}
+
+func runConfigGetContextsCommand(cmd *cobra.Command, _ []string) error {
+	current := getCurrentContext()
+	contexts := getContexts()
+	names := make([]string, 0, len(contexts))
+	for name := range contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := make(contextList, len(names))
+	for i, name := range names {
+		list[i] = &contextInfo{
+			Name:       name,
+			Current:    name == current,
+			Controller: viper.GetString(getContextKey(name, "controller")),
+			Namespace:  viper.GetString(getContextKey(name, "namespace")),
+			Group:      viper.GetString(getContextKey(name, "group")),
+			App:        viper.GetString(getContextKey(name, "app")),
+		}
+	}
+	return printResult(cmd, list)
+}
+
+// contextInfo is the output representation of a configuration context
+type contextInfo struct {
+	Name       string `json:"name" yaml:"name"`
+	Current    bool   `json:"current" yaml:"current"`
+	Controller string `json:"controller,omitempty" yaml:"controller,omitempty"`
+	Namespace  string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Group      string `json:"group,omitempty" yaml:"group,omitempty"`
+	App        string `json:"app,omitempty" yaml:"app,omitempty"`
+}
+
+// contextList is the output representation of a list of configuration contexts
+type contextList []*contextInfo
+
+func (l contextList) writeTable(out io.Writer, includeHeaders bool) {
+	writer := new(tabwriter.Writer)
+	writer.Init(out, 0, 0, 3, ' ', tabwriter.FilterHTML)
+	if includeHeaders {
+		fmt.Fprintln(writer, "CURRENT\tNAME\tCONTROLLER\tNAMESPACE\tGROUP\tAPP")
+	}
+	for _, context := range l {
+		current := ""
+		if context.Current {
+			current = "*"
+		}
+		fmt.Fprintln(writer, fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s", current, context.Name, context.Controller, context.Namespace, context.Group, context.App))
+	}
+	writer.Flush()
+}
+
+func newConfigUseContextCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "use-context <name>",
+		Short: "Set the current configuration context",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runConfigUseContextCommand,
+	}
+}
+
+func runConfigUseContextCommand(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	if !hasContext(name) {
+		return newExitError(ExitInvalidInput, fmt.Errorf("unknown context %s", name))
+	}
+	updateConfig(currentContextKey, name)
+	if err := writeConfig(); err != nil {
+		return err
+	}
+	return printResult(cmd, fmt.Sprintf("Switched to context %s", name))
+}
+
+func newConfigSetContextCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set-context <name>",
+		Short: "Create or update a configuration context",
+		Long: `Create or update a configuration context
+
+Context names may only contain lowercase letters, digits and '-'.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runConfigSetContextCommand,
+	}
+	cmd.Flags().String("controller", "", "the controller address")
+	cmd.Flags().String("namespace", "", "the partition group namespace")
+	cmd.Flags().String("group", "", "the partition group name")
+	cmd.Flags().String("app", "", "the application name")
+	cmd.Flags().Bool(tlsKey, false, "connect to the controller using TLS")
+	cmd.Flags().String(tlsCACertKey, "", "the CA certificate used to verify the controller")
+	cmd.Flags().String(tlsCertKey, "", "the client certificate used for mutual TLS")
+	cmd.Flags().String(tlsKeyKey, "", "the client key used for mutual TLS")
+	cmd.Flags().Bool(tlsInsecureSkipVerify, false, "skip verification of the controller certificate")
+	cmd.Flags().String(tokenKey, "", "the bearer token used to authenticate requests")
+	cmd.Flags().String(tokenFileKey, "", "a file containing the bearer token used to authenticate requests")
+	cmd.Flags().String(tokenCommandKey, "", "a credential plugin command that prints a bearer token")
+	return cmd
+}
+
+func runConfigSetContextCommand(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	if !contextNamePattern.MatchString(name) {
+		return newExitError(ExitInvalidInput, fmt.Errorf("invalid context name %s: names may only contain lowercase letters, digits and '-'", name))
+	}
+	context := viper.GetStringMap(getContextKey(name, ""))
+	for _, key := range contextKeys {
+		if cmd.Flags().Changed(key) {
+			context[key] = cmd.Flags().Lookup(key).Value.String()
+		}
+	}
+
+	contexts := viper.GetStringMap(contextsKey)
+	contexts[name] = context
+	updateConfig(contextsKey, contexts)
+	if err := writeConfig(); err != nil {
+		return err
+	}
+	return printResult(cmd, fmt.Sprintf("Set context %s", name))
+}
+
+func newConfigDeleteContextCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete-context <name>",
+		Short: "Delete a configuration context",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runConfigDeleteContextCommand,
+	}
+}
+
+func runConfigDeleteContextCommand(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	if !hasContext(name) {
+		return newExitError(ExitInvalidInput, fmt.Errorf("unknown context %s", name))
+	}
+
+	contexts := viper.GetStringMap(contextsKey)
+	delete(contexts, name)
+	updateConfig(contextsKey, contexts)
+	if viper.GetString(currentContextKey) == name {
+		updateConfig(currentContextKey, "")
+	}
+	if err := writeConfig(); err != nil {
+		return err
+	}
+	return printResult(cmd, fmt.Sprintf("Deleted context %s", name))
+}
@@ -0,0 +1,212 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"text/template"
+)
+
+const (
+	defaultControllerService = "atomix-controller"
+	defaultControllerNS      = "kube-system"
+	defaultControllerImage   = "atomix/kubernetes-controller:latest"
+	defaultControllerPort    = 5679
+	defaultClusterDomain     = "cluster.local"
+)
+
+func newControllerCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "controller {k8s}",
+		Short: "Deploy and connect to Atomix controllers",
+	}
+	cmd.AddCommand(newControllerK8sCommand())
+	return cmd
+}
+
+func newControllerK8sCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "k8s {deploy,connect}",
+		Short: "Deploy and connect to Atomix controllers in Kubernetes",
+	}
+	cmd.PersistentFlags().StringP("service", "s", defaultControllerService, "the name of the controller service")
+	cmd.PersistentFlags().StringP("namespace", "n", defaultControllerNS, "the Kubernetes namespace of the controller")
+	cmd.PersistentFlags().Int("port", defaultControllerPort, "the controller port")
+	cmd.AddCommand(newControllerK8sDeployCommand())
+	cmd.AddCommand(newControllerK8sConnectCommand())
+	return cmd
+}
+
+func newControllerK8sDeployCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "deploy",
+		Short: "Print the Kubernetes manifests for the controller",
+		Args:  cobra.NoArgs,
+		RunE:  runControllerK8sDeployCommand,
+	}
+	cmd.Flags().String("image", defaultControllerImage, "the controller image")
+	cmd.Flags().String("image-pull-policy", "IfNotPresent", "the controller image pull policy")
+	cmd.Flags().Int("replicas", 1, "the number of controller replicas")
+	return cmd
+}
+
+// controllerManifest holds the values used to render the controller manifests
+type controllerManifest struct {
+	Name            string
+	Namespace       string
+	Image           string
+	ImagePullPolicy string
+	Replicas        int
+	Port            int
+}
+
+func runControllerK8sDeployCommand(cmd *cobra.Command, _ []string) error {
+	manifest := controllerManifest{}
+	manifest.Name, _ = cmd.Flags().GetString("service")
+	manifest.Namespace, _ = cmd.Flags().GetString("namespace")
+	manifest.Image, _ = cmd.Flags().GetString("image")
+	manifest.ImagePullPolicy, _ = cmd.Flags().GetString("image-pull-policy")
+	manifest.Replicas, _ = cmd.Flags().GetInt("replicas")
+	manifest.Port, _ = cmd.Flags().GetInt("port")
+	if manifest.Replicas < 1 {
+		return newExitError(ExitInvalidInput, fmt.Errorf("invalid number of replicas %d", manifest.Replicas))
+	}
+	return controllerTemplate.Execute(cmd.OutOrStdout(), manifest)
+}
+
+func newControllerK8sConnectCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "connect",
+		Short: "Connect the CLI to a controller running in Kubernetes",
+		Args:  cobra.NoArgs,
+		RunE:  runControllerK8sConnectCommand,
+	}
+	cmd.Flags().String("cluster-domain", defaultClusterDomain, "the Kubernetes cluster domain")
+	return cmd
+}
+
+func runControllerK8sConnectCommand(cmd *cobra.Command, _ []string) error {
+	service, _ := cmd.Flags().GetString("service")
+	namespace, _ := cmd.Flags().GetString("namespace")
+	port, _ := cmd.Flags().GetInt("port")
+	domain, _ := cmd.Flags().GetString("cluster-domain")
+	address := fmt.Sprintf("%s.%s.svc.%s:%d", service, namespace, domain, port)
+	if err := setClientController(address); err != nil {
+		return err
+	}
+	return printResult(cmd, getClientController())
+}
+
+var controllerTemplate = template.Must(template.New("controller").Parse(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: {{ .Name }}
+  namespace: {{ .Namespace }}
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: {{ .Name }}
+rules:
+- apiGroups:
+  - ""
+  resources:
+  - pods
+  - services
+  - endpoints
+  - persistentvolumeclaims
+  - events
+  - configmaps
+  - secrets
+  verbs:
+  - '*'
+- apiGroups:
+  - apps
+  resources:
+  - deployments
+  - statefulsets
+  verbs:
+  - '*'
+- apiGroups:
+  - policy
+  resources:
+  - poddisruptionbudgets
+  verbs:
+  - '*'
+- apiGroups:
+  - cloud.atomix.io
+  - k8s.atomix.io
+  resources:
+  - '*'
+  verbs:
+  - '*'
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: {{ .Name }}
+subjects:
+- kind: ServiceAccount
+  name: {{ .Name }}
+  namespace: {{ .Namespace }}
+roleRef:
+  kind: ClusterRole
+  name: {{ .Name }}
+  apiGroup: rbac.authorization.k8s.io
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Name }}
+  namespace: {{ .Namespace }}
+spec:
+  replicas: {{ .Replicas }}
+  selector:
+    matchLabels:
+      name: {{ .Name }}
+  template:
+    metadata:
+      labels:
+        name: {{ .Name }}
+    spec:
+      serviceAccountName: {{ .Name }}
+      containers:
+      - name: controller
+        image: {{ .Image }}
+        imagePullPolicy: {{ .ImagePullPolicy }}
+        ports:
+        - name: control
+          containerPort: {{ .Port }}
+        env:
+        - name: CONTROLLER_NAME
+          value: {{ .Name }}
+        - name: CONTROLLER_NAMESPACE
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.namespace
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{ .Name }}
+  namespace: {{ .Namespace }}
+spec:
+  selector:
+    name: {{ .Name }}
+  ports:
+  - name: control
+    port: {{ .Port }}
+`))
@@ -0,0 +1,65 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestControllerK8sDeploy(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		golden string
+	}{
+		{
+			name:   "defaults",
+			args:   []string{"controller", "k8s", "deploy"},
+			golden: "controller.golden",
+		},
+		{
+			name: "overrides",
+			args: []string{"controller", "k8s", "deploy", "-s", "my-controller", "-n", "atomix",
+				"--port", "1234", "--image", "atomix/controller:test", "--image-pull-policy", "Always", "--replicas", "3"},
+			golden: "controller-overrides.golden",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, _, err := executeCommand(test.args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", test.golden)
+			if *update {
+				if err := ioutil.WriteFile(golden, []byte(output), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expected, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != string(expected) {
+				t.Errorf("manifest does not match %s; run with -update to regenerate it\n%s", golden, output)
+			}
+		})
+	}
+}
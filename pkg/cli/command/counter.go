@@ -15,9 +15,13 @@
 package command
 
 import (
+	"context"
 	"fmt"
+	"github.com/atomix/go-client/pkg/client"
 	"github.com/atomix/go-client/pkg/client/counter"
+	"github.com/atomix/go-client/pkg/client/primitive"
 	"github.com/spf13/cobra"
+	"io"
 )
 
 func newCounterCommand() *cobra.Command {
@@ -40,126 +44,155 @@ func newCounterCommand() *cobra.Command {
 	return cmd
 }
 
-func newCounterFromName(cmd *cobra.Command) counter.Counter {
+func newCounterFromName(cmd *cobra.Command) (counter.Counter, error) {
 	name, _ := cmd.Flags().GetString("name")
-	group := newGroupFromName(cmd, name)
-	m, err := group.GetCounter(newTimeoutContext(cmd), getPrimitiveName(name))
+	p, err := newPrimitiveFromName(cmd, counter.Type, name, func(ctx context.Context, group *client.PartitionGroup, name string) (primitive.Primitive, error) {
+		return group.GetCounter(ctx, name)
+	})
 	if err != nil {
-		ExitWithError(ExitError, err)
+		return nil, err
 	}
-	return m
+	return p.(counter.Counter), nil
+}
+
+// counterValue is the output representation of a counter value
+type counterValue struct {
+	Value int64 `json:"value" yaml:"value"`
+}
+
+func (v *counterValue) writeTable(writer io.Writer, _ bool) {
+	fmt.Fprintln(writer, v.Value)
 }
 
 func newCounterCreateCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:  "create",
 		Args: cobra.NoArgs,
-		Run:  runCounterCreateCommand,
+		RunE: runCounterCreateCommand,
 	}
 }
 
-func runCounterCreateCommand(cmd *cobra.Command, _ []string) {
-	counter := newCounterFromName(cmd)
-	counter.Close()
-	ExitWithOutput(fmt.Sprintf("Created %s", counter.Name().String()))
+func runCounterCreateCommand(cmd *cobra.Command, _ []string) error {
+	counter, err := newCounterFromName(cmd)
+	if err != nil {
+		return err
+	}
+	closePrimitive(counter)
+	return printResult(cmd, fmt.Sprintf("Created %s", counter.Name().String()))
 }
 
 func newCounterDeleteCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:  "delete",
 		Args: cobra.NoArgs,
-		Run:  runCounterDeleteCommand,
+		RunE: runCounterDeleteCommand,
 	}
 }
 
-func runCounterDeleteCommand(cmd *cobra.Command, _ []string) {
-	counter := newCounterFromName(cmd)
-	err := counter.Delete()
+func runCounterDeleteCommand(cmd *cobra.Command, _ []string) error {
+	counter, err := newCounterFromName(cmd)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		ExitWithOutput(fmt.Sprintf("Deleted %s", counter.Name().String()))
+		return err
 	}
+	if err := deletePrimitive(counter); err != nil {
+		return err
+	}
+	return printResult(cmd, fmt.Sprintf("Deleted %s", counter.Name().String()))
 }
 
 func newCounterGetCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:  "get",
 		Args: cobra.NoArgs,
-		Run:  runCounterGetCommand,
+		RunE: withRetry(runCounterGetCommand),
 	}
 }
 
-func runCounterGetCommand(cmd *cobra.Command, _ []string) {
-	counter := newCounterFromName(cmd)
-	value, err := counter.Get(newTimeoutContext(cmd))
+func runCounterGetCommand(cmd *cobra.Command, _ []string) error {
+	counter, err := newCounterFromName(cmd)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	value, err := counter.Get(ctx)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		ExitWithOutput(value)
+		return err
 	}
+	return printResult(cmd, &counterValue{Value: value})
 }
 
 func newCounterSetCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:  "set",
 		Args: cobra.NoArgs,
-		Run:  runCounterSetCommand,
+		RunE: runCounterSetCommand,
 	}
 	cmd.Flags().Int64P("value", "v", 0, "the value to set")
 	cmd.MarkFlagRequired("value")
 	return cmd
 }
 
-func runCounterSetCommand(cmd *cobra.Command, _ []string) {
-	counter := newCounterFromName(cmd)
-	value, _ := cmd.Flags().GetInt64("value")
-	err := counter.Set(newTimeoutContext(cmd), value)
+func runCounterSetCommand(cmd *cobra.Command, _ []string) error {
+	counter, err := newCounterFromName(cmd)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		ExitWithOutput(value)
+		return err
+	}
+	value, _ := cmd.Flags().GetInt64("value")
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	if err := counter.Set(ctx, value); err != nil {
+		return err
 	}
+	return printResult(cmd, &counterValue{Value: value})
 }
 
 func newCounterIncrementCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:  "increment",
 		Args: cobra.NoArgs,
-		Run:  runCounterIncrementCommand,
+		RunE: runCounterIncrementCommand,
 	}
 	cmd.Flags().Int64P("delta", "d", 1, "the delta by which to increment the counter")
 	return cmd
 }
 
-func runCounterIncrementCommand(cmd *cobra.Command, _ []string) {
-	counter := newCounterFromName(cmd)
+func runCounterIncrementCommand(cmd *cobra.Command, _ []string) error {
+	counter, err := newCounterFromName(cmd)
+	if err != nil {
+		return err
+	}
 	delta, _ := cmd.Flags().GetInt64("delta")
-	value, err := counter.Increment(newTimeoutContext(cmd), delta)
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	value, err := counter.Increment(ctx, delta)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		ExitWithOutput(value)
+		return err
 	}
+	return printResult(cmd, &counterValue{Value: value})
 }
 
 func newCounterDecrementCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:  "decrement",
 		Args: cobra.NoArgs,
-		Run:  runCounterDecrementCommand,
+		RunE: runCounterDecrementCommand,
 	}
 	cmd.Flags().Int64P("delta", "d", 1, "the delta by which to decrement the counter")
 	return cmd
 }
 
-func runCounterDecrementCommand(cmd *cobra.Command, _ []string) {
-	counter := newCounterFromName(cmd)
+func runCounterDecrementCommand(cmd *cobra.Command, _ []string) error {
+	counter, err := newCounterFromName(cmd)
+	if err != nil {
+		return err
+	}
 	delta, _ := cmd.Flags().GetInt64("delta")
-	value, err := counter.Decrement(newTimeoutContext(cmd), delta)
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	value, err := counter.Decrement(ctx, delta)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		ExitWithOutput(value)
+		return err
 	}
+	return printResult(cmd, &counterValue{Value: value})
 }
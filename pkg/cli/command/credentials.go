@@ -0,0 +1,171 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc/metadata"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	tokenKey        = "token"
+	tokenFileKey    = "token-file"
+	tokenCommandKey = "token-command"
+)
+
+const (
+	authorizationHeader = "authorization"
+	tokenExpiryMargin   = 10 * time.Second
+)
+
+// credentials holds the bearer token attached to controller and primitive requests
+var credentials string
+
+func addCredentialFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().String(tokenKey, "", "the bearer token used to authenticate requests")
+	cmd.PersistentFlags().String(tokenFileKey, "", "a file containing the bearer token used to authenticate requests")
+	bindConfigFlag(tokenKey, cmd.PersistentFlags().Lookup(tokenKey))
+	bindConfigFlag(tokenFileKey, cmd.PersistentFlags().Lookup(tokenFileKey))
+}
+
+// execCredential is the output of a credential plugin
+// Plugins may also print a bare token, in which case the token is not cached.
+type execCredential struct {
+	Token               string    `json:"token"`
+	ExpirationTimestamp time.Time `json:"expirationTimestamp"`
+}
+
+func (c *execCredential) isValid() bool {
+	return c.Token != "" && time.Now().Add(tokenExpiryMargin).Before(c.ExpirationTimestamp)
+}
+
+// loadCredentials resolves the bearer token from the token, token-file or token-command settings, in that order
+// Token files are read and credential plugins are run only if configured by a flag, the environment or a file
+// chosen by the user, so that a configuration file discovered in a cloned project cannot run commands or send
+// the contents of arbitrary files to a controller of its choosing.
+func loadCredentials() error {
+	if token := getConfig(tokenKey); token != "" {
+		credentials = token
+		return nil
+	}
+	if file := getConfig(tokenFileKey); file != "" {
+		if err := checkTrustedConfig(tokenFileKey); err != nil {
+			return err
+		}
+		bytes, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		credentials = strings.TrimSpace(string(bytes))
+		return nil
+	}
+	if command := getConfig(tokenCommandKey); command != "" {
+		if err := checkTrustedConfig(tokenCommandKey); err != nil {
+			return err
+		}
+		token, err := getExecToken(command)
+		if err != nil {
+			return err
+		}
+		credentials = token
+		return nil
+	}
+	credentials = ""
+	return nil
+}
+
+// checkTrustedConfig returns an error if the given key is only set by a configuration file the user did not choose
+func checkTrustedConfig(key string) error {
+	if configTrusted || getConfigFlag(key) != nil || isEnvSet(key) {
+		return nil
+	}
+	return newExitError(ExitInvalidInput, fmt.Errorf("%s is not allowed in %s; set it in %s, a file selected by --config or %s, or %s",
+		key, viper.ConfigFileUsed(), "~/.atomix/config.yaml", configFileEnv, getConfigEnv(key)))
+}
+
+// getExecToken returns a token from the cache or by running the given credential plugin command
+func getExecToken(command string) (string, error) {
+	path, err := getTokenCachePath(command)
+	if err != nil {
+		return "", err
+	}
+	if bytes, err := ioutil.ReadFile(path); err == nil {
+		cached := &execCredential{}
+		if err := json.Unmarshal(bytes, cached); err == nil && cached.isValid() {
+			return cached.Token, nil
+		}
+	}
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	plugin := exec.Command("/bin/sh", "-c", command)
+	plugin.Stdout = stdout
+	plugin.Stderr = stderr
+	if err := plugin.Run(); err != nil {
+		return "", fmt.Errorf("credential plugin failed: %s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	output := bytes.TrimSpace(stdout.Bytes())
+	credential := &execCredential{}
+	if err := json.Unmarshal(output, credential); err != nil {
+		return string(output), nil
+	}
+	if credential.Token == "" {
+		return "", fmt.Errorf("credential plugin returned no token")
+	}
+	if credential.isValid() {
+		if err := writeTokenCache(path, output); err != nil {
+			return "", err
+		}
+	}
+	return credential.Token, nil
+}
+
+// getTokenCachePath returns the path at which tokens returned by the given command are cached
+func getTokenCachePath(command string) (string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".atomix", "cache", "tokens", fmt.Sprintf("%x.json", sha256.Sum256([]byte(command)))), nil
+}
+
+func writeTokenCache(path string, bytes []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, bytes, 0600)
+}
+
+// withCredentials attaches the bearer token to the outgoing request metadata of the given context
+func withCredentials(ctx context.Context) context.Context {
+	if credentials == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, authorizationHeader, "Bearer "+credentials)
+}
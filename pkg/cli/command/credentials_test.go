@@ -0,0 +1,68 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestUntrustedCredentials(t *testing.T) {
+	setTestHome(t)
+	secret := filepath.Join(t.TempDir(), "secret")
+	if err := ioutil.WriteFile(secret, []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		config string
+		args   []string
+		code   int
+	}{
+		{
+			name:   "token file in project",
+			config: "token-file: " + secret + "\n",
+			code:   ExitInvalidInput,
+		},
+		{
+			name:   "token command in project",
+			config: "token-command: echo token\n",
+			code:   ExitInvalidInput,
+		},
+		{
+			name:   "token file in project context",
+			config: "current-context: test\ncontexts:\n  test:\n    token-file: " + secret + "\n",
+			code:   ExitInvalidInput,
+		},
+		{
+			name:   "token file flag",
+			config: "token-command: echo token\n",
+			args:   []string{"--token-file", secret},
+			code:   ExitBadConnection,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setTestProject(t, test.config)
+			args := append([]string{"groups", "--controller", "localhost:1", "--retries", "0", "--timeout", "1s"}, test.args...)
+			_, _, err := executeCommand(args...)
+			if code := getTestExitCode(err); code != test.code {
+				t.Errorf("expected exit code %d, got %d (%v)", test.code, code, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"github.com/atomix/cli/pkg/dev"
+	"github.com/spf13/cobra"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func newDevCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dev {serve}",
+		Short: "Run Atomix locally for development and testing",
+	}
+	cmd.AddCommand(newDevServeCommand())
+	return cmd
+}
+
+func newDevServeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve an in-memory Atomix controller",
+		Args:  cobra.NoArgs,
+		RunE:  runDevServeCommand,
+	}
+	cmd.Flags().Int("port", 5679, "the port on which to serve the controller")
+	cmd.Flags().StringP("group", "g", "default", "the name of a partition group to create on startup")
+	cmd.Flags().IntP("partitions", "p", 1, "the number of partitions in the partition group")
+	cmd.Flags().IntP("partitionSize", "s", 1, "the size of partitions in the partition group")
+	cmd.Flags().Duration("timeout", 15*time.Second, "the timeout for creating the partition group")
+	return cmd
+}
+
+func runDevServeCommand(cmd *cobra.Command, _ []string) error {
+	port, _ := cmd.Flags().GetInt("port")
+	group, _ := cmd.Flags().GetString("group")
+	partitions, _ := cmd.Flags().GetInt("partitions")
+	partitionSize, _ := cmd.Flags().GetInt("partitionSize")
+
+	controller := dev.NewController(port)
+	if err := controller.Start(); err != nil {
+		return err
+	}
+	defer controller.Stop()
+
+	if group != "" {
+		ctx, cancel := newTimeoutContext(cmd)
+		defer cancel()
+		if err := controller.CreateGroup(ctx, getClientNamespace(), group, partitions, partitionSize); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), fmt.Sprintf("Serving controller at %s", controller.Address()))
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+	<-ch
+	return nil
+}
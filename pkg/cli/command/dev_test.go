@@ -0,0 +1,344 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"github.com/atomix/cli/pkg/dev"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// startTestController starts an in-process controller with a default partition group
+func startTestController(t *testing.T) *dev.Controller {
+	setTestHome(t)
+	controller := dev.NewController(0)
+	if err := controller.Start(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := controller.CreateGroup(ctx, "default", "default", 3, 1); err != nil {
+		controller.Stop()
+		t.Fatal(err)
+	}
+	return controller
+}
+
+func TestMapCommands(t *testing.T) {
+	controller := startTestController(t)
+	defer controller.Stop()
+
+	tests := []struct {
+		name   string
+		args   []string
+		output string
+		code   int
+	}{
+		{
+			name:   "put",
+			args:   []string{"map", "put", "--name", "test", "--key", "foo", "--value", "bar", "-o", "go-template={{.key}}={{.value}}"},
+			output: "foo=bar",
+		},
+		{
+			name:   "get",
+			args:   []string{"map", "get", "--name", "test", "--key", "foo", "-o", "go-template={{.value}}"},
+			output: "bar",
+		},
+		{
+			name:   "raw value",
+			args:   []string{"map", "get", "--name", "default/default/test", "--key", "foo", "--raw"},
+			output: "bar",
+		},
+		{
+			name: "put if absent",
+			args: []string{"map", "put", "--name", "test", "--key", "foo", "--value", "baz", "--if-absent"},
+			code: ExitConditionFailed,
+		},
+		{
+			name:   "compare and swap",
+			args:   []string{"map", "cas", "--name", "test", "--key", "foo", "--old", "bar", "--new", "baz", "-o", "go-template={{.value}}"},
+			output: "baz",
+		},
+		{
+			name:   "keys",
+			args:   []string{"map", "keys", "--name", "test", "--no-headers"},
+			output: "foo\n",
+		},
+		{
+			name:   "size",
+			args:   []string{"map", "size", "--name", "test"},
+			output: "1\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			args := append([]string{"--controller", controller.Address()}, test.args...)
+			output, _, err := executeCommand(args...)
+			if code := getTestExitCode(err); code != test.code {
+				t.Fatalf("expected exit code %d, got %d (%v)", test.code, code, err)
+			}
+			if output != test.output {
+				t.Errorf("expected output %q, got %q", test.output, output)
+			}
+		})
+	}
+}
+
+func TestExecSharesPrimitives(t *testing.T) {
+	controller := startTestController(t)
+	defer controller.Stop()
+
+	// The lock is held by the session shared by the commands run by exec
+	SetInput(strings.NewReader("lock lock --name test\nlock get --name test\n"))
+	defer SetInput(os.Stdin)
+	output, _, err := executeCommand("--controller", controller.Address(), "exec", "-o", "go-template={{(index . 1).result}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output != "true" {
+		t.Errorf("expected output %q, got %q", "true", output)
+	}
+
+	// The lock is released when exec closes the session
+	output, _, err = executeCommand("--controller", controller.Address(), "lock", "get", "--name", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output != "false\n" {
+		t.Errorf("expected output %q, got %q", "false\n", output)
+	}
+}
+
+func TestMapImport(t *testing.T) {
+	controller := startTestController(t)
+	defer controller.Stop()
+
+	if _, _, err := executeCommand("--controller", controller.Address(), "map", "put", "--name", "test", "--key", "foo", "--value", "bar"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := `{"key":"foo","value":"baz"}
+{"key":"bar","value":"baz"}
+`
+	tests := []struct {
+		name   string
+		args   []string
+		output string
+		code   int
+	}{
+		{
+			name: "fail on conflict",
+			args: []string{"map", "import", "--name", "test", "--format", "ndjson", "--on-conflict", "fail"},
+			code: ExitConditionFailed,
+		},
+		{
+			name:   "dry run",
+			args:   []string{"map", "import", "--name", "test", "--format", "ndjson", "--dry-run", "-o", "go-template={{range .}}{{.key}}:{{.action}} {{end}}"},
+			output: "foo:update bar:insert ",
+		},
+		{
+			name:   "skip on conflict",
+			args:   []string{"map", "import", "--name", "test", "--format", "ndjson", "--on-conflict", "skip", "-o", "go-template={{range .}}{{.key}}:{{.action}} {{end}}"},
+			output: "foo:skip bar:insert ",
+		},
+		{
+			name:   "overwrite on conflict",
+			args:   []string{"map", "import", "--name", "test", "--format", "ndjson", "-o", "go-template={{range .}}{{.key}}:{{.action}} {{end}}"},
+			output: "foo:update bar:unchanged ",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			SetInput(strings.NewReader(entries))
+			defer SetInput(os.Stdin)
+			args := append([]string{"--controller", controller.Address()}, test.args...)
+			output, _, err := executeCommand(args...)
+			if code := getTestExitCode(err); code != test.code {
+				t.Fatalf("expected exit code %d, got %d (%v)", test.code, code, err)
+			}
+			if output != test.output {
+				t.Errorf("expected output %q, got %q", test.output, output)
+			}
+		})
+	}
+
+	output, _, err := executeCommand("--controller", controller.Address(), "map", "get", "--name", "test", "--key", "foo", "--raw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output != "baz" {
+		t.Errorf("expected value %q, got %q", "baz", output)
+	}
+
+	// Files with duplicate keys are rejected before any entry is written
+	SetInput(strings.NewReader(`{"key":"baz","value":"foo"}
+{"key":"baz","value":"bar"}
+`))
+	defer SetInput(os.Stdin)
+	_, _, err = executeCommand("--controller", controller.Address(), "map", "import", "--name", "test", "--format", "ndjson")
+	if code := getTestExitCode(err); code != ExitInvalidInput {
+		t.Fatalf("expected exit code %d, got %d (%v)", ExitInvalidInput, code, err)
+	}
+	output, _, err = executeCommand("--controller", controller.Address(), "map", "size", "--name", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(output) != "2" {
+		t.Errorf("expected size 2, got %q", output)
+	}
+}
+
+func TestMapWatch(t *testing.T) {
+	// Interrupts sent to stop the watch are also delivered here so they cannot terminate the test
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT)
+	defer signal.Stop(signals)
+
+	tests := []struct {
+		name string
+		stop func(controller *dev.Controller)
+		code int
+	}{
+		{
+			name: "interrupted",
+			stop: func(*dev.Controller) {
+				syscall.Kill(os.Getpid(), syscall.SIGINT)
+			},
+			code: ExitSuccess,
+		},
+		{
+			name: "stream closed",
+			stop: func(controller *dev.Controller) {
+				for key, p := range sharedPrimitives {
+					p.Close()
+					delete(sharedPrimitives, key)
+				}
+			},
+			code: ExitBadConnection,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := startTestController(t)
+			defer controller.Stop()
+
+			// The watch shares its map with the test so its session can be closed by the test
+			if err := shareClients(); err != nil {
+				t.Fatal(err)
+			}
+			defer closeSharedClients()
+			if _, _, err := executeCommand("--controller", controller.Address(), "map", "put", "--name", "test", "--key", "foo", "--value", "bar"); err != nil {
+				t.Fatal(err)
+			}
+
+			type result struct {
+				output string
+				err    error
+			}
+			results := make(chan result, 1)
+			go func() {
+				output, _, err := executeCommand("--controller", controller.Address(), "map", "watch", "--name", "test", "--replay", "-o", "json")
+				results <- result{output, err}
+			}()
+			time.Sleep(500 * time.Millisecond)
+			test.stop(controller)
+
+			select {
+			case result := <-results:
+				if code := getTestExitCode(result.err); code != test.code {
+					t.Errorf("expected exit code %d, got %d (%v)", test.code, code, result.err)
+				}
+				if !strings.Contains(result.output, `"key":"foo"`) {
+					t.Errorf("expected replayed event, got %q", result.output)
+				}
+			case <-time.After(10 * time.Second):
+				t.Fatal("watch did not stop")
+			}
+		})
+	}
+}
+
+func TestMapEntries(t *testing.T) {
+	controller := startTestController(t)
+	defer controller.Stop()
+
+	for i := 19; i >= 0; i-- {
+		key := fmt.Sprintf("k%d", i)
+		if _, _, err := executeCommand("--controller", controller.Address(), "map", "put", "--name", "test", "--key", key, "--value", key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		output string
+		code   int
+	}{
+		{
+			name:   "limit",
+			args:   []string{"map", "keys", "--name", "test", "--no-headers", "--limit", "2"},
+			output: "k0\nk1\n",
+		},
+		{
+			name:   "prefix",
+			args:   []string{"map", "keys", "--name", "test", "--no-headers", "--prefix", "k1"},
+			output: "k1\nk10\nk11\nk12\nk13\nk14\nk15\nk16\nk17\nk18\nk19\n",
+		},
+		{
+			name:   "prefix and limit",
+			args:   []string{"map", "keys", "--name", "test", "--no-headers", "--prefix", "k1", "--limit", "3"},
+			output: "k1\nk10\nk11\n",
+		},
+		{
+			name:   "regex",
+			args:   []string{"map", "entries", "--name", "test", "-o", "go-template={{range .}}{{.key}}={{.value}} {{end}}", "--regex", "^k[2-4]$"},
+			output: "k2=k2 k3=k3 k4=k4 ",
+		},
+		{
+			name:   "limit larger than map",
+			args:   []string{"map", "keys", "--name", "test", "--no-headers", "--regex", "9$", "--limit", "5"},
+			output: "k19\nk9\n",
+		},
+		{
+			name: "invalid regex",
+			args: []string{"map", "keys", "--name", "test", "--regex", "("},
+			code: ExitInvalidInput,
+		},
+		{
+			name: "invalid limit",
+			args: []string{"map", "keys", "--name", "test", "--limit", "-1"},
+			code: ExitInvalidInput,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			args := append([]string{"--controller", controller.Address()}, test.args...)
+			output, _, err := executeCommand(args...)
+			if code := getTestExitCode(err); code != test.code {
+				t.Fatalf("expected exit code %d, got %d (%v)", test.code, code, err)
+			}
+			if output != test.output {
+				t.Errorf("expected output %q, got %q", test.output, output)
+			}
+		})
+	}
+}
@@ -15,9 +15,14 @@
 package command
 
 import (
+	"context"
 	"fmt"
+	"github.com/atomix/go-client/pkg/client"
 	"github.com/atomix/go-client/pkg/client/election"
+	"github.com/atomix/go-client/pkg/client/primitive"
 	"github.com/spf13/cobra"
+	"io"
+	"strings"
 )
 
 func newElectionCommand() *cobra.Command {
@@ -39,98 +44,136 @@ func newElectionCommand() *cobra.Command {
 	return cmd
 }
 
-func newElectionFromName(cmd *cobra.Command) election.Election {
+func newElectionFromName(cmd *cobra.Command) (election.Election, error) {
 	name, _ := cmd.Flags().GetString("name")
-	group := newGroupFromName(cmd, name)
-	m, err := group.GetElection(newTimeoutContext(cmd), getPrimitiveName(name))
+	p, err := newPrimitiveFromName(cmd, election.Type, name, func(ctx context.Context, group *client.PartitionGroup, name string) (primitive.Primitive, error) {
+		return group.GetElection(ctx, name)
+	})
 	if err != nil {
-		ExitWithError(ExitError, err)
+		return nil, err
 	}
-	return m
+	return p.(election.Election), nil
+}
+
+// electionTerm is the output representation of an election term
+type electionTerm struct {
+	ID         uint64   `json:"id" yaml:"id"`
+	Leader     string   `json:"leader" yaml:"leader"`
+	Candidates []string `json:"candidates" yaml:"candidates"`
+}
+
+func newElectionTerm(term *election.Term) *electionTerm {
+	if term == nil {
+		return nil
+	}
+	return &electionTerm{
+		ID:         term.ID,
+		Leader:     term.Leader,
+		Candidates: term.Candidates,
+	}
+}
+
+func (t *electionTerm) writeTable(writer io.Writer, _ bool) {
+	fmt.Fprintln(writer, fmt.Sprintf("ID:         %d", t.ID))
+	fmt.Fprintln(writer, fmt.Sprintf("Leader:     %s", t.Leader))
+	fmt.Fprintln(writer, fmt.Sprintf("Candidates: %s", strings.Join(t.Candidates, ", ")))
 }
 
 func newElectionCreateCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:  "create",
 		Args: cobra.NoArgs,
-		Run:  runElectionCreateCommand,
+		RunE: runElectionCreateCommand,
 	}
 }
 
-func runElectionCreateCommand(cmd *cobra.Command, _ []string) {
-	election := newElectionFromName(cmd)
-	election.Close()
-	ExitWithOutput(fmt.Sprintf("Created %s", election.Name().String()))
+func runElectionCreateCommand(cmd *cobra.Command, _ []string) error {
+	election, err := newElectionFromName(cmd)
+	if err != nil {
+		return err
+	}
+	closePrimitive(election)
+	return printResult(cmd, fmt.Sprintf("Created %s", election.Name().String()))
 }
 
 func newElectionDeleteCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:  "delete",
 		Args: cobra.NoArgs,
-		Run:  runElectionDeleteCommand,
+		RunE: runElectionDeleteCommand,
 	}
 }
 
-func runElectionDeleteCommand(cmd *cobra.Command, _ []string) {
-	election := newElectionFromName(cmd)
-	err := election.Delete()
+func runElectionDeleteCommand(cmd *cobra.Command, _ []string) error {
+	election, err := newElectionFromName(cmd)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		ExitWithOutput(fmt.Sprintf("Deleted %s", election.Name().String()))
+		return err
 	}
+	if err := deletePrimitive(election); err != nil {
+		return err
+	}
+	return printResult(cmd, fmt.Sprintf("Deleted %s", election.Name().String()))
 }
 
 func newElectionGetCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:  "get",
 		Args: cobra.NoArgs,
-		Run:  runElectionGetCommand,
+		RunE: withRetry(runElectionGetCommand),
 	}
 }
 
-func runElectionGetCommand(cmd *cobra.Command, _ []string) {
-	election := newElectionFromName(cmd)
-	term, err := election.GetTerm(newTimeoutContext(cmd))
+func runElectionGetCommand(cmd *cobra.Command, _ []string) error {
+	election, err := newElectionFromName(cmd)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		ExitWithOutput(term)
+		return err
 	}
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	term, err := election.GetTerm(ctx)
+	if err != nil {
+		return err
+	}
+	return printResult(cmd, newElectionTerm(term))
 }
 
 func newElectionEnterCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:  "enter",
 		Args: cobra.NoArgs,
-		Run:  runElectionEnterCommand,
+		RunE: runElectionEnterCommand,
 	}
 }
 
-func runElectionEnterCommand(cmd *cobra.Command, _ []string) {
-	election := newElectionFromName(cmd)
-	term, err := election.Enter(newTimeoutContext(cmd))
+func runElectionEnterCommand(cmd *cobra.Command, _ []string) error {
+	election, err := newElectionFromName(cmd)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	term, err := election.Enter(ctx)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		ExitWithOutput(term)
+		return err
 	}
+	return printResult(cmd, newElectionTerm(term))
 }
 
 func newElectionLeaveCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:  "leave",
 		Args: cobra.NoArgs,
-		Run:  runElectionLeaveCommand,
+		RunE: runElectionLeaveCommand,
 	}
 }
 
-func runElectionLeaveCommand(cmd *cobra.Command, _ []string) {
-	election := newElectionFromName(cmd)
-	_, err := election.Leave(newTimeoutContext(cmd))
+func runElectionLeaveCommand(cmd *cobra.Command, _ []string) error {
+	election, err := newElectionFromName(cmd)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		ExitWithSuccess()
+		return err
 	}
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	_, err = election.Leave(ctx)
+	return err
 }
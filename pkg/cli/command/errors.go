@@ -0,0 +1,148 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"net"
+	"os"
+	"strings"
+)
+
+const (
+	errorFormatText = "text"
+	errorFormatJSON = "json"
+)
+
+func addErrorFlags(cmd *cobra.Command) {
+	viper.SetDefault("error-format", errorFormatText)
+	cmd.PersistentFlags().String("error-format", viper.GetString("error-format"), "the error output format (text, json)")
+	bindConfigFlag("error-format", cmd.PersistentFlags().Lookup("error-format"))
+}
+
+// exitError is an error that carries the code with which the CLI should exit
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func newExitError(code int, err error) error {
+	return &exitError{
+		code: code,
+		err:  err,
+	}
+}
+
+// getErrorCode returns the gRPC status code for the given error
+// Errors returned by the go-client that are not gRPC errors are mapped to the status code that best
+// describes them.
+func getErrorCode(err error) codes.Code {
+	if e, ok := err.(*exitError); ok {
+		err = e.err
+	}
+	if st, ok := status.FromError(err); ok {
+		return st.Code()
+	}
+
+	switch err {
+	case context.DeadlineExceeded:
+		return codes.DeadlineExceeded
+	case context.Canceled:
+		return codes.Canceled
+	}
+
+	if _, ok := err.(*os.PathError); ok {
+		return codes.DataLoss
+	}
+	if _, ok := err.(net.Error); ok {
+		return codes.Unavailable
+	}
+
+	message := err.Error()
+	switch {
+	case message == "write condition failed":
+		return codes.FailedPrecondition
+	case message == "write lock failed":
+		return codes.Aborted
+	case strings.HasPrefix(message, "unknown partition group"):
+		return codes.NotFound
+	}
+	return codes.Unknown
+}
+
+// GetExitCode returns the code with which the CLI should exit for the given error
+func GetExitCode(err error) int {
+	if e, ok := err.(*exitError); ok {
+		return e.code
+	}
+	switch getErrorCode(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return ExitBadConnection
+	case codes.InvalidArgument, codes.OutOfRange:
+		return ExitInvalidInput
+	case codes.Unimplemented:
+		return ExitBadFeature
+	case codes.Canceled:
+		return ExitInterrupted
+	case codes.DataLoss:
+		return ExitIO
+	case codes.FailedPrecondition:
+		return ExitConditionFailed
+	}
+	return ExitError
+}
+
+// getErrorMessage returns the error message without the gRPC status prefix
+func getErrorMessage(err error) string {
+	if e, ok := err.(*exitError); ok {
+		err = e.err
+	}
+	if st, ok := status.FromError(err); ok {
+		return st.Message()
+	}
+	return err.Error()
+}
+
+// errorInfo is the JSON representation of an error
+type errorInfo struct {
+	Code    int    `json:"code"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// PrintError writes the given error to the writer in the format selected by --error-format
+func PrintError(writer io.Writer, err error) {
+	if viper.GetString("error-format") == errorFormatJSON {
+		bytes, _ := json.Marshal(&errorInfo{
+			Code:    GetExitCode(err),
+			Status:  getErrorCode(err).String(),
+			Message: getErrorMessage(err),
+		})
+		fmt.Fprintln(writer, string(bytes))
+	} else {
+		fmt.Fprintln(writer, "Error:", err)
+	}
+}
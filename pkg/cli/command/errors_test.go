@@ -0,0 +1,50 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"os"
+	"testing"
+)
+
+func TestGetExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{"exit error", newExitError(ExitBadArgs, errors.New("bad args")), ExitBadArgs},
+		{"unavailable", status.Error(codes.Unavailable, "unavailable"), ExitBadConnection},
+		{"deadline exceeded", context.DeadlineExceeded, ExitBadConnection},
+		{"invalid argument", status.Error(codes.InvalidArgument, "invalid"), ExitInvalidInput},
+		{"unimplemented", status.Error(codes.Unimplemented, "unimplemented"), ExitBadFeature},
+		{"canceled", context.Canceled, ExitInterrupted},
+		{"path error", &os.PathError{Op: "open", Path: "x", Err: os.ErrNotExist}, ExitIO},
+		{"write condition failed", errors.New("write condition failed"), ExitConditionFailed},
+		{"unknown group", errors.New("unknown partition group foo"), ExitError},
+		{"unknown error", errors.New("boom"), ExitError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if code := GetExitCode(test.err); code != test.code {
+				t.Errorf("expected exit code %d, got %d", test.code, code)
+			}
+		})
+	}
+}
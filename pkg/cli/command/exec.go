@@ -0,0 +1,156 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"strings"
+)
+
+func newExecCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "exec",
+		Short: "Run commands from a file over a shared connection",
+		Long: `Run commands from a file over a shared connection
+
+The file contains one command per line, without the atomix prefix. Empty lines and lines
+starting with # are ignored. Partition groups are resolved once and shared by all commands.
+Execution stops at the first failing command unless --continue-on-error is set.`,
+		Args: cobra.NoArgs,
+		RunE: runExecCommand,
+	}
+	cmd.Flags().StringP("file", "f", "-", "the file from which to read commands, or - for stdin")
+	cmd.Flags().Bool("continue-on-error", false, "continue running commands after a command fails")
+	return cmd
+}
+
+// execResult is the output representation of the result of a command run by exec
+type execResult struct {
+	Line     int         `json:"line" yaml:"line"`
+	Command  string      `json:"command" yaml:"command"`
+	ExitCode int         `json:"exitCode" yaml:"exitCode"`
+	Result   interface{} `json:"result,omitempty" yaml:"result,omitempty"`
+	Error    string      `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// execResultList is the output representation of the results of the commands run by exec
+type execResultList []*execResult
+
+func (l execResultList) writeTable(out io.Writer, _ bool) {
+	for _, result := range l {
+		fmt.Fprintln(out, fmt.Sprintf("[%d] %s (exit %d)", result.Line, result.Command, result.ExitCode))
+		if result.Result != nil {
+			fmt.Fprintln(out, result.Result)
+		}
+		if result.Error != "" {
+			fmt.Fprintln(out, fmt.Sprintf("Error: %s", result.Error))
+		}
+	}
+}
+
+func runExecCommand(cmd *cobra.Command, _ []string) error {
+	file, _ := cmd.Flags().GetString("file")
+	continueOnError, _ := cmd.Flags().GetBool("continue-on-error")
+
+	reader := input
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		reader = f
+	}
+
+	if err := shareClients(); err != nil {
+		return err
+	}
+	defer closeSharedClients()
+
+	// Commands are run with JSON output so their results can be embedded in structured output
+	format := getOutputFormat(cmd)
+	globalArgs := []string{}
+	for _, arg := range getGlobalArgs(cmd) {
+		if !strings.HasPrefix(arg, "--output=") {
+			globalArgs = append(globalArgs, arg)
+		}
+	}
+	if format != outputTable {
+		globalArgs = append(globalArgs, "--output=json")
+	}
+
+	results := execResultList{}
+	var failure error
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		result := &execResult{
+			Line:    line,
+			Command: text,
+		}
+		results = append(results, result)
+
+		args, err := splitCommandLine(text)
+		if err == nil {
+			output := &bytes.Buffer{}
+			err = runCommandLine(output, globalArgs, args)
+			result.Result = getExecOutput(output.Bytes(), format)
+		}
+		commandFlags = cmd.Flags()
+		if err != nil {
+			result.ExitCode = GetExitCode(err)
+			result.Error = getErrorMessage(err)
+			if failure == nil {
+				failure = newExitError(result.ExitCode, fmt.Errorf("line %d: %s", line, result.Error))
+			}
+			if !continueOnError {
+				break
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if err := printResult(cmd, results); err != nil {
+		return err
+	}
+	return failure
+}
+
+// getExecOutput returns the output of a command as a value to be included in the exec result
+func getExecOutput(output []byte, format string) interface{} {
+	output = bytes.TrimSpace(output)
+	if len(output) == 0 {
+		return nil
+	}
+	if format != outputTable {
+		var value interface{}
+		if err := json.Unmarshal(output, &value); err == nil {
+			return value
+		}
+	}
+	return string(output)
+}
@@ -21,7 +21,7 @@ import (
 	"github.com/atomix/go-client/pkg/client"
 	"github.com/golang/protobuf/proto"
 	"github.com/spf13/cobra"
-	"os"
+	"io"
 	"text/tabwriter"
 	"time"
 )
@@ -30,7 +30,7 @@ func newGroupCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "group {set,get,create,delete}",
 		Short: "Manage partition groups and partitions",
-		Run:   runGroupGetCommand,
+		RunE:  withRetry(runGroupGetCommand),
 	}
 	cmd.PersistentFlags().Duration("timeout", 15*time.Second, "the operation timeout")
 	cmd.AddCommand(newGroupSetCommand())
@@ -44,69 +44,99 @@ func newGroupsCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "groups",
 		Short: "Get a list of partition groups",
-		Run:   runGroupsCommand,
+		RunE:  withRetry(runGroupsCommand),
 	}
 	cmd.PersistentFlags().Duration("timeout", 15*time.Second, "the operation timeout")
 	cmd.Flags().Bool("no-headers", false, "exclude headers from the output")
 	return cmd
 }
 
-func printGroups(groups []*client.PartitionGroup, includeHeaders bool) {
+// groupInfo is the output representation of a partition group
+type groupInfo struct {
+	Name          string `json:"name" yaml:"name"`
+	Namespace     string `json:"namespace" yaml:"namespace"`
+	Partitions    int    `json:"partitions" yaml:"partitions"`
+	PartitionSize int    `json:"partitionSize" yaml:"partitionSize"`
+}
+
+func newGroupInfo(group *client.PartitionGroup) *groupInfo {
+	return &groupInfo{
+		Name:          group.Name,
+		Namespace:     group.Namespace,
+		Partitions:    group.Partitions,
+		PartitionSize: group.PartitionSize,
+	}
+}
+
+func (g *groupInfo) writeTable(writer io.Writer, _ bool) {
+	fmt.Fprintln(writer, fmt.Sprintf("Name:            %s", g.Name))
+	fmt.Fprintln(writer, fmt.Sprintf("Namespace:       %s", g.Namespace))
+	fmt.Fprintln(writer, fmt.Sprintf("Partitions:      %d", g.Partitions))
+	fmt.Fprintln(writer, fmt.Sprintf("Partitions Size: %d", g.PartitionSize))
+}
+
+// groupList is the output representation of a list of partition groups
+type groupList []*groupInfo
+
+func newGroupList(groups []*client.PartitionGroup) groupList {
+	list := make(groupList, len(groups))
+	for i, group := range groups {
+		list[i] = newGroupInfo(group)
+	}
+	return list
+}
+
+func (l groupList) writeTable(out io.Writer, includeHeaders bool) {
 	writer := new(tabwriter.Writer)
-	writer.Init(os.Stdout, 0, 0, 3, ' ', tabwriter.FilterHTML)
+	writer.Init(out, 0, 0, 3, ' ', tabwriter.FilterHTML)
 	if includeHeaders {
 		fmt.Fprintln(writer, "NAME\tPARTITIONS\tSIZE")
 	}
-	for _, group := range groups {
+	for _, group := range l {
 		fmt.Fprintln(writer, fmt.Sprintf("%s\t%d\t%d", group.Name, group.Partitions, group.PartitionSize))
 	}
 	writer.Flush()
 }
 
-func printGroup(group *client.PartitionGroup) {
-	fmt.Println(fmt.Sprintf("Name:            %s", group.Name))
-	fmt.Println(fmt.Sprintf("Namespace:       %s", group.Namespace))
-	fmt.Println(fmt.Sprintf("Partitions:      %d", group.Partitions))
-	fmt.Println(fmt.Sprintf("Partitions Size: %d", group.PartitionSize))
-}
-
-func runGroupsCommand(cmd *cobra.Command, _ []string) {
-	client := newClientFromEnv()
-	groups, err := client.GetGroups(newTimeoutContext(cmd))
+func runGroupsCommand(cmd *cobra.Command, _ []string) error {
+	client, err := newClientFromEnv()
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		noHeaders, _ := cmd.Flags().GetBool("no-headers")
-		printGroups(groups, !noHeaders)
-		ExitWithSuccess()
+		return err
+	}
+	defer client.Close()
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	groups, err := client.GetGroups(ctx)
+	if err != nil {
+		return err
 	}
+	return printResult(cmd, newGroupList(groups))
 }
 
 func newGroupSetCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:  "set <group>",
 		Args: cobra.ExactArgs(1),
-		Run:  runGroupSetCommand,
+		RunE: runGroupSetCommand,
 	}
 }
 
-func runGroupSetCommand(cmd *cobra.Command, args []string) {
+func runGroupSetCommand(cmd *cobra.Command, args []string) error {
 	if err := setClientGroup(args[0]); err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		ExitWithOutput(getClientGroup())
+		return err
 	}
+	return printResult(cmd, getClientGroup())
 }
 
 func newGroupGetCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:  "get [group]>",
 		Args: cobra.MaximumNArgs(1),
-		Run:  runGroupGetCommand,
+		RunE: withRetry(runGroupGetCommand),
 	}
 }
 
-func runGroupGetCommand(cmd *cobra.Command, args []string) {
+func runGroupGetCommand(cmd *cobra.Command, args []string) error {
 	var name string
 	if len(args) == 0 {
 		name = getClientGroup()
@@ -114,21 +144,25 @@ func runGroupGetCommand(cmd *cobra.Command, args []string) {
 		name = args[0]
 	}
 
-	client := newClientFromGroup(name)
-	group, err := client.GetGroup(newTimeoutContext(cmd), getGroupName(name))
+	client, err := newClientFromGroup(name)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		printGroup(group)
-		ExitWithSuccess()
+		return err
 	}
+	defer client.Close()
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	group, err := client.GetGroup(ctx, getGroupName(name))
+	if err != nil {
+		return err
+	}
+	return printResult(cmd, newGroupInfo(group))
 }
 
 func newGroupCreateCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:  "create <group>",
 		Args: cobra.ExactArgs(1),
-		Run:  runGroupCreateCommand,
+		RunE: runGroupCreateCommand,
 	}
 	cmd.Flags().String("protocol", "raft", "the protocol to run in the partition group")
 	cmd.Flags().IntP("partitions", "p", 1, "the number of partitions to create")
@@ -136,9 +170,13 @@ func newGroupCreateCommand() *cobra.Command {
 	return cmd
 }
 
-func runGroupCreateCommand(cmd *cobra.Command, args []string) {
+func runGroupCreateCommand(cmd *cobra.Command, args []string) error {
 	name := args[0]
-	client := newClientFromGroup(name)
+	client, err := newClientFromGroup(name)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
 
 	partitions, _ := cmd.Flags().GetInt("partitions")
 	partitionSize, _ := cmd.Flags().GetInt("partitionSize")
@@ -152,30 +190,31 @@ func runGroupCreateCommand(cmd *cobra.Command, args []string) {
 		protocol = &log.LogProtocol{}
 	}
 
-	group, err := client.CreateGroup(newTimeoutContext(cmd), getGroupName(name), partitions, partitionSize, protocol)
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	group, err := client.CreateGroup(ctx, getGroupName(name), partitions, partitionSize, protocol)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		printGroup(group)
-		ExitWithSuccess()
+		return err
 	}
+	return printResult(cmd, newGroupInfo(group))
 }
 
 func newGroupDeleteCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:  "delete <group>",
 		Args: cobra.ExactArgs(1),
-		Run:  runGroupDeleteCommand,
+		RunE: runGroupDeleteCommand,
 	}
 }
 
-func runGroupDeleteCommand(cmd *cobra.Command, args []string) {
+func runGroupDeleteCommand(cmd *cobra.Command, args []string) error {
 	name := args[0]
-	client := newClientFromGroup(name)
-	err := client.DeleteGroup(newTimeoutContext(cmd), getGroupName(name))
+	client, err := newClientFromGroup(name)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		ExitWithSuccess()
+		return err
 	}
+	defer client.Close()
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	return client.DeleteGroup(ctx, getGroupName(name))
 }
@@ -0,0 +1,102 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"io"
+	"os"
+	"strings"
+)
+
+func newInitCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Initialize the CLI configuration file",
+		Args:  cobra.NoArgs,
+		RunE:  runInitCommand,
+	}
+	cmd.Flags().BoolP("interactive", "i", false, "prompt for the configuration values")
+	cmd.Flags().BoolP("force", "f", false, "overwrite an existing configuration file")
+	return cmd
+}
+
+func runInitCommand(cmd *cobra.Command, _ []string) error {
+	// The file is created where it was selected explicitly or in the home directory, never in a
+	// project or system configuration file found while searching for the configuration
+	var err error
+	path := getExplicitConfigFile()
+	if path == "" {
+		path, err = getDefaultConfigFile()
+		if err != nil {
+			return err
+		}
+	}
+
+	force, _ := cmd.Flags().GetBool("force")
+	if _, err := os.Stat(path); err == nil && !force {
+		return newExitError(ExitInvalidInput, fmt.Errorf("%s already exists; use --force to overwrite it", path))
+	}
+
+	// A new file only holds the initial settings; nothing else is copied from the configuration in use
+	config := viper.New()
+	interactive, _ := cmd.Flags().GetBool("interactive")
+	reader := bufio.NewReader(input)
+	for _, key := range []string{"controller", "namespace", "app", "group"} {
+		value := getInitValue(cmd, key)
+		if interactive {
+			value, err = prompt(cmd.OutOrStdout(), reader, key, value)
+			if err != nil {
+				return err
+			}
+		}
+		config.Set(key, value)
+	}
+
+	if err := writeConfigFile(config, path); err != nil {
+		return err
+	}
+	return printResult(cmd, fmt.Sprintf("Created %s", path))
+}
+
+// getInitValue returns the initial value of the given key for a new configuration file
+// Values read from a configuration file that is not trusted, such as a project file, are not copied to
+// the user's configuration; the built-in default is used instead.
+func getInitValue(cmd *cobra.Command, key string) string {
+	if configTrusted || getConfigFlag(key) != nil || isEnvSet(key) {
+		return getConfig(key)
+	}
+	if flag := cmd.Flag(key); flag != nil {
+		return flag.DefValue
+	}
+	return ""
+}
+
+// prompt prompts for the value of the given key, returning the default value if no value is entered
+func prompt(writer io.Writer, reader *bufio.Reader, key string, defaultValue string) (string, error) {
+	fmt.Fprintf(writer, "%s [%s]: ", key, defaultValue)
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	value := strings.TrimSpace(line)
+	if value == "" {
+		return defaultValue, nil
+	}
+	return value, nil
+}
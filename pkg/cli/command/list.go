@@ -17,8 +17,12 @@ package command
 import (
 	"context"
 	"fmt"
+	"github.com/atomix/go-client/pkg/client"
 	"github.com/atomix/go-client/pkg/client/list"
+	"github.com/atomix/go-client/pkg/client/primitive"
 	"github.com/spf13/cobra"
+	"io"
+	"text/tabwriter"
 )
 
 func newListCommand() *cobra.Command {
@@ -44,196 +48,277 @@ func newListCommand() *cobra.Command {
 	return cmd
 }
 
-func newListFromName(cmd *cobra.Command) list.List {
+func newListFromName(cmd *cobra.Command) (list.List, error) {
 	name, _ := cmd.Flags().GetString("name")
-	group := newGroupFromName(cmd, name)
-	m, err := group.GetList(newTimeoutContext(cmd), getPrimitiveName(name))
+	p, err := newPrimitiveFromName(cmd, list.Type, name, func(ctx context.Context, group *client.PartitionGroup, name string) (primitive.Primitive, error) {
+		return group.GetList(ctx, name)
+	})
 	if err != nil {
-		ExitWithError(ExitError, err)
+		return nil, err
 	}
-	return m
+	return p.(list.List), nil
+}
+
+// listItem is the output representation of a list item
+type listItem struct {
+	Index int    `json:"index" yaml:"index"`
+	Value string `json:"value" yaml:"value"`
+}
+
+func newListItem(index int, value []byte, encoding string) *listItem {
+	return &listItem{
+		Index: index,
+		Value: encodeValue(value, encoding),
+	}
+}
+
+func (i *listItem) writeTable(writer io.Writer, _ bool) {
+	fmt.Fprintln(writer, i.Value)
+}
+
+// listItemList is the output representation of the items in a list
+type listItemList []*listItem
+
+func (l listItemList) writeTable(out io.Writer, includeHeaders bool) {
+	writer := new(tabwriter.Writer)
+	writer.Init(out, 0, 0, 3, ' ', tabwriter.FilterHTML)
+	if includeHeaders {
+		fmt.Fprintln(writer, "INDEX\tVALUE")
+	}
+	for _, item := range l {
+		fmt.Fprintln(writer, fmt.Sprintf("%d\t%s", item.Index, item.Value))
+	}
+	writer.Flush()
 }
 
 func newListCreateCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:  "create",
 		Args: cobra.NoArgs,
-		Run:  runListCreateCommand,
+		RunE: runListCreateCommand,
 	}
 }
 
-func runListCreateCommand(cmd *cobra.Command, _ []string) {
-	list := newListFromName(cmd)
-	list.Close()
-	ExitWithOutput(fmt.Sprintf("Created %s", list.Name().String()))
+func runListCreateCommand(cmd *cobra.Command, _ []string) error {
+	list, err := newListFromName(cmd)
+	if err != nil {
+		return err
+	}
+	closePrimitive(list)
+	return printResult(cmd, fmt.Sprintf("Created %s", list.Name().String()))
 }
 
 func newListDeleteCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:  "delete",
 		Args: cobra.NoArgs,
-		Run:  runListDeleteCommand,
+		RunE: runListDeleteCommand,
 	}
 }
 
-func runListDeleteCommand(cmd *cobra.Command, _ []string) {
-	list := newListFromName(cmd)
-	err := list.Delete()
+func runListDeleteCommand(cmd *cobra.Command, _ []string) error {
+	list, err := newListFromName(cmd)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		ExitWithOutput(fmt.Sprintf("Deleted %s", list.Name().String()))
+		return err
 	}
+	if err := deletePrimitive(list); err != nil {
+		return err
+	}
+	return printResult(cmd, fmt.Sprintf("Deleted %s", list.Name().String()))
 }
 
 func newListGetCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:  "get",
 		Args: cobra.NoArgs,
-		Run:  runListGetCommand,
+		RunE: withRetry(runListGetCommand),
 	}
 	cmd.Flags().IntP("index", "i", -1, "the index to get")
 	cmd.MarkFlagRequired("index")
+	addEncodingFlag(cmd)
+	addRawFlag(cmd)
 	return cmd
 }
 
-func runListGetCommand(cmd *cobra.Command, _ []string) {
-	list := newListFromName(cmd)
+func runListGetCommand(cmd *cobra.Command, _ []string) error {
+	encoding, err := getEncoding(cmd)
+	if err != nil {
+		return err
+	}
+	list, err := newListFromName(cmd)
+	if err != nil {
+		return err
+	}
 	index, _ := cmd.Flags().GetInt("index")
-	value, err := list.Get(newTimeoutContext(cmd), index)
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	value, err := list.Get(ctx, index)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else if value != nil {
-		ExitWithOutput(string(value))
-	} else {
-		ExitWithOutput(nil)
+		return err
+	} else if value == nil {
+		return printValue(cmd, nil, nil)
 	}
+	return printValue(cmd, value, newListItem(index, value, encoding))
 }
 
 func newListAppendCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:  "append",
 		Args: cobra.NoArgs,
-		Run:  runListAppendCommand,
+		RunE: runListAppendCommand,
 	}
-	cmd.Flags().StringP("value", "v", "", "the value to append")
-	cmd.MarkFlagRequired("value")
+	addValueFlags(cmd, "the value to append")
 	return cmd
 }
 
-func runListAppendCommand(cmd *cobra.Command, _ []string) {
-	l := newListFromName(cmd)
-	value, _ := cmd.Flags().GetString("value")
-	err := l.Append(newTimeoutContext(cmd), []byte(value))
+func runListAppendCommand(cmd *cobra.Command, _ []string) error {
+	value, err := getValue(cmd)
+	if err != nil {
+		return err
+	}
+	l, err := newListFromName(cmd)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		ExitWithOutput(nil)
+		return err
 	}
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	if err := l.Append(ctx, value); err != nil {
+		return err
+	}
+	return printResult(cmd, nil)
 }
 
 func newListInsertCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:  "insert",
 		Args: cobra.NoArgs,
-		Run:  runListInsertCommand,
+		RunE: runListInsertCommand,
 	}
 	cmd.Flags().IntP("index", "i", -1, "the index to which to insert the value")
 	cmd.MarkFlagRequired("index")
-	cmd.Flags().StringP("value", "v", "", "the value to insert")
-	cmd.MarkFlagRequired("value")
+	addValueFlags(cmd, "the value to insert")
 	return cmd
 }
 
-func runListInsertCommand(cmd *cobra.Command, _ []string) {
-	l := newListFromName(cmd)
-	index, _ := cmd.Flags().GetInt("index")
-	value, _ := cmd.Flags().GetString("value")
-	err := l.Insert(newTimeoutContext(cmd), int(index), []byte(value))
+func runListInsertCommand(cmd *cobra.Command, _ []string) error {
+	value, err := getValue(cmd)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		ExitWithOutput(nil)
+		return err
+	}
+	l, err := newListFromName(cmd)
+	if err != nil {
+		return err
+	}
+	index, _ := cmd.Flags().GetInt("index")
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	if err := l.Insert(ctx, int(index), value); err != nil {
+		return err
 	}
+	return printResult(cmd, nil)
 }
 
 func newListRemoveCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:  "remove",
 		Args: cobra.NoArgs,
-		Run:  runListRemoveCommand,
+		RunE: runListRemoveCommand,
 	}
 	cmd.Flags().IntP("index", "i", -1, "the index to remove")
 	cmd.MarkFlagRequired("index")
 	cmd.Flags().Int64P("version", "v", 0, "the entry version")
+	addEncodingFlag(cmd)
 	return cmd
 }
 
-func runListRemoveCommand(cmd *cobra.Command, _ []string) {
-	m := newListFromName(cmd)
+func runListRemoveCommand(cmd *cobra.Command, _ []string) error {
+	encoding, err := getEncoding(cmd)
+	if err != nil {
+		return err
+	}
+	m, err := newListFromName(cmd)
+	if err != nil {
+		return err
+	}
 	index, _ := cmd.Flags().GetInt("index")
-	value, err := m.Remove(newTimeoutContext(cmd), int(index))
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	value, err := m.Remove(ctx, int(index))
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else if value != nil {
-		ExitWithOutput(string(value))
-	} else {
-		ExitWithOutput(nil)
+		return err
+	} else if value == nil {
+		return printResult(cmd, nil)
 	}
+	return printResult(cmd, newListItem(index, value, encoding))
 }
 
 func newListItemsCommand() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:  "items",
 		Args: cobra.NoArgs,
-		Run:  runListItemsCommand,
+		RunE: withRetry(runListItemsCommand),
 	}
+	cmd.Flags().Bool("no-headers", false, "exclude headers from the output")
+	addEncodingFlag(cmd)
+	return cmd
 }
 
-func runListItemsCommand(cmd *cobra.Command, _ []string) {
-	m := newListFromName(cmd)
-	ch := make(chan []byte)
-	err := m.Items(context.TODO(), ch)
+func runListItemsCommand(cmd *cobra.Command, _ []string) error {
+	encoding, err := getEncoding(cmd)
+	if err != nil {
+		return err
+	}
+	m, err := newListFromName(cmd)
 	if err != nil {
-		ExitWithError(ExitError, err)
+		return err
+	}
+	ch := make(chan []byte)
+	if err := m.Items(withCredentials(context.TODO()), ch); err != nil {
+		return err
 	}
+	items := listItemList{}
 	for value := range ch {
-		println(string(value))
+		items = append(items, newListItem(len(items), value, encoding))
 	}
+	return printResult(cmd, items)
 }
 
 func newListSizeCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:  "size",
 		Args: cobra.NoArgs,
-		Run:  runListSizeCommand,
+		RunE: withRetry(runListSizeCommand),
 	}
 }
 
-func runListSizeCommand(cmd *cobra.Command, _ []string) {
-	list := newListFromName(cmd)
-	size, err := list.Len(newTimeoutContext(cmd))
+func runListSizeCommand(cmd *cobra.Command, _ []string) error {
+	list, err := newListFromName(cmd)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	size, err := list.Len(ctx)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		ExitWithOutput(size)
+		return err
 	}
+	return printResult(cmd, size)
 }
 
 func newListClearCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:  "clear",
 		Args: cobra.NoArgs,
-		Run:  runListClearCommand,
+		RunE: runListClearCommand,
 	}
 }
 
-func runListClearCommand(cmd *cobra.Command, _ []string) {
-	list := newListFromName(cmd)
-	err := list.Clear(newTimeoutContext(cmd))
+func runListClearCommand(cmd *cobra.Command, _ []string) error {
+	list, err := newListFromName(cmd)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		ExitWithSuccess()
+		return err
 	}
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	return list.Clear(ctx)
 }
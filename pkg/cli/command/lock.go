@@ -15,9 +15,13 @@
 package command
 
 import (
+	"context"
 	"fmt"
+	"github.com/atomix/go-client/pkg/client"
 	"github.com/atomix/go-client/pkg/client/lock"
+	"github.com/atomix/go-client/pkg/client/primitive"
 	"github.com/spf13/cobra"
+	"io"
 )
 
 func newLockCommand() *cobra.Command {
@@ -39,122 +43,140 @@ func newLockCommand() *cobra.Command {
 	return cmd
 }
 
-func newLockFromName(cmd *cobra.Command) lock.Lock {
+func newLockFromName(cmd *cobra.Command) (lock.Lock, error) {
 	name, _ := cmd.Flags().GetString("name")
-	group := newGroupFromName(cmd, name)
-	m, err := group.GetLock(newTimeoutContext(cmd), getPrimitiveName(name))
+	p, err := newPrimitiveFromName(cmd, lock.Type, name, func(ctx context.Context, group *client.PartitionGroup, name string) (primitive.Primitive, error) {
+		return group.GetLock(ctx, name)
+	})
 	if err != nil {
-		ExitWithError(ExitError, err)
+		return nil, err
 	}
-	return m
+	return p.(lock.Lock), nil
+}
+
+// lockVersion is the output representation of an acquired lock
+type lockVersion struct {
+	Version uint64 `json:"version" yaml:"version"`
+}
+
+func (v *lockVersion) writeTable(writer io.Writer, _ bool) {
+	fmt.Fprintln(writer, v.Version)
 }
 
 func newLockCreateCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:  "create",
 		Args: cobra.NoArgs,
-		Run:  runLockCreateCommand,
+		RunE: runLockCreateCommand,
 	}
 }
 
-func runLockCreateCommand(cmd *cobra.Command, _ []string) {
-	lock := newLockFromName(cmd)
-	lock.Close()
-	ExitWithOutput(fmt.Sprintf("Created %s", lock.Name().String()))
+func runLockCreateCommand(cmd *cobra.Command, _ []string) error {
+	lock, err := newLockFromName(cmd)
+	if err != nil {
+		return err
+	}
+	closePrimitive(lock)
+	return printResult(cmd, fmt.Sprintf("Created %s", lock.Name().String()))
 }
 
 func newLockDeleteCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:  "delete",
 		Args: cobra.NoArgs,
-		Run:  runLockDeleteCommand,
+		RunE: runLockDeleteCommand,
 	}
 }
 
-func runLockDeleteCommand(cmd *cobra.Command, _ []string) {
-	lock := newLockFromName(cmd)
-	err := lock.Delete()
+func runLockDeleteCommand(cmd *cobra.Command, _ []string) error {
+	lock, err := newLockFromName(cmd)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		ExitWithOutput(fmt.Sprintf("Deleted %s", lock.Name().String()))
+		return err
+	}
+	if err := deletePrimitive(lock); err != nil {
+		return err
 	}
+	return printResult(cmd, fmt.Sprintf("Deleted %s", lock.Name().String()))
 }
 
 func newLockLockCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:  "lock",
 		Args: cobra.NoArgs,
-		Run:  runLockLockCommand,
+		RunE: runLockLockCommand,
 	}
 }
 
-func runLockLockCommand(cmd *cobra.Command, _ []string) {
-	lock := newLockFromName(cmd)
-	version, err := lock.Lock(newTimeoutContext(cmd))
+func runLockLockCommand(cmd *cobra.Command, _ []string) error {
+	lock, err := newLockFromName(cmd)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		ExitWithOutput(version)
+		return err
 	}
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	version, err := lock.Lock(ctx)
+	if err != nil {
+		return err
+	}
+	return printResult(cmd, &lockVersion{Version: version})
 }
 
 func newLockGetCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:  "get",
 		Args: cobra.NoArgs,
-		Run:  runLockGetCommand,
+		RunE: withRetry(runLockGetCommand),
 	}
 	cmd.Flags().Uint64P("version", "v", 0, "the lock version")
 	return cmd
 }
 
-func runLockGetCommand(cmd *cobra.Command, _ []string) {
-	l := newLockFromName(cmd)
+func runLockGetCommand(cmd *cobra.Command, _ []string) error {
+	l, err := newLockFromName(cmd)
+	if err != nil {
+		return err
+	}
 	version, _ := cmd.Flags().GetUint64("version")
-	if version == 0 {
-		locked, err := l.IsLocked(newTimeoutContext(cmd))
-		if err != nil {
-			ExitWithError(ExitError, err)
-		} else {
-			ExitWithOutput(locked)
-		}
-	} else {
-		locked, err := l.IsLocked(newTimeoutContext(cmd), lock.IfVersion(version))
-		if err != nil {
-			ExitWithError(ExitError, err)
-		} else {
-			ExitWithOutput(locked)
-		}
+	opts := []lock.IsLockedOption{}
+	if version > 0 {
+		opts = append(opts, lock.IfVersion(version))
 	}
+
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	locked, err := l.IsLocked(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	return printResult(cmd, locked)
 }
 
 func newLockUnlockCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:  "unlock",
 		Args: cobra.NoArgs,
-		Run:  runLockUnlockCommand,
+		RunE: runLockUnlockCommand,
 	}
 	cmd.Flags().Uint64P("version", "v", 0, "the lock version")
 	return cmd
 }
 
-func runLockUnlockCommand(cmd *cobra.Command, _ []string) {
-	l := newLockFromName(cmd)
+func runLockUnlockCommand(cmd *cobra.Command, _ []string) error {
+	l, err := newLockFromName(cmd)
+	if err != nil {
+		return err
+	}
 	version, _ := cmd.Flags().GetUint64("version")
-	if version == 0 {
-		unlocked, err := l.Unlock(newTimeoutContext(cmd))
-		if err != nil {
-			ExitWithError(ExitError, err)
-		} else {
-			ExitWithOutput(unlocked)
-		}
-	} else {
-		unlocked, err := l.Unlock(newTimeoutContext(cmd), lock.IfVersion(version))
-		if err != nil {
-			ExitWithError(ExitError, err)
-		} else {
-			ExitWithOutput(unlocked)
-		}
+	opts := []lock.UnlockOption{}
+	if version > 0 {
+		opts = append(opts, lock.IfVersion(version))
+	}
+
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	unlocked, err := l.Unlock(ctx, opts...)
+	if err != nil {
+		return err
 	}
+	return printResult(cmd, unlocked)
 }
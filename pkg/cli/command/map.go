@@ -15,15 +15,23 @@
 package command
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"github.com/atomix/go-client/pkg/client"
 	"github.com/atomix/go-client/pkg/client/map"
+	"github.com/atomix/go-client/pkg/client/primitive"
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"io"
+	"sort"
+	"text/tabwriter"
 )
 
 func newMapCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "map {create,put,get,remove,size,clear,delete}",
+		Use:   "map {create,put,replace,cas,edit,get,remove,entries,keys,size,clear,watch,export,import,diff,delete}",
 		Short: "Manage the state of a distributed map",
 	}
 	addClientFlags(cmd)
@@ -35,128 +43,332 @@ func newMapCommand() *cobra.Command {
 	cmd.AddCommand(newMapCreateCommand())
 	cmd.AddCommand(newMapGetCommand())
 	cmd.AddCommand(newMapPutCommand())
+	cmd.AddCommand(newMapReplaceCommand())
+	cmd.AddCommand(newMapCASCommand())
+	cmd.AddCommand(newMapEditCommand())
 	cmd.AddCommand(newMapRemoveCommand())
+	cmd.AddCommand(newMapEntriesCommand())
 	cmd.AddCommand(newMapKeysCommand())
 	cmd.AddCommand(newMapSizeCommand())
 	cmd.AddCommand(newMapClearCommand())
 	cmd.AddCommand(newMapDeleteCommand())
+	cmd.AddCommand(newMapWatchCommand())
+	cmd.AddCommand(newMapExportCommand())
+	cmd.AddCommand(newMapImportCommand())
+	cmd.AddCommand(newMapDiffCommand())
 	return cmd
 }
 
-func newMapFromName(cmd *cobra.Command) _map.Map {
+func newMapFromName(cmd *cobra.Command) (_map.Map, error) {
 	name, _ := cmd.Flags().GetString("name")
-	group := newGroupFromName(cmd, name)
-	m, err := group.GetMap(newTimeoutContext(cmd), getPrimitiveName(name))
+	return newMapFromNameString(cmd, name)
+}
+
+func newMapFromNameString(cmd *cobra.Command, name string) (_map.Map, error) {
+	p, err := newPrimitiveFromName(cmd, _map.Type, name, func(ctx context.Context, group *client.PartitionGroup, name string) (primitive.Primitive, error) {
+		return group.GetMap(ctx, name)
+	})
 	if err != nil {
-		ExitWithError(ExitError, err)
+		return nil, err
+	}
+	return p.(_map.Map), nil
+}
+
+// mapEntry is the output representation of a map entry
+type mapEntry struct {
+	Key     string `json:"key" yaml:"key"`
+	Value   string `json:"value" yaml:"value"`
+	Version int64  `json:"version" yaml:"version"`
+}
+
+func newMapEntry(kv *_map.Entry, encoding string) *mapEntry {
+	return &mapEntry{
+		Key:     kv.Key,
+		Value:   encodeValue(kv.Value, encoding),
+		Version: kv.Version,
+	}
+}
+
+func (e *mapEntry) writeTable(writer io.Writer, _ bool) {
+	fmt.Fprintln(writer, fmt.Sprintf("key: %s", e.Key))
+	fmt.Fprintln(writer, fmt.Sprintf("value: %s", e.Value))
+	fmt.Fprintln(writer, fmt.Sprintf("version: %d", e.Version))
+}
+
+// mapEntryList is the output representation of a list of map entries
+type mapEntryList []*mapEntry
+
+func (l mapEntryList) writeTable(out io.Writer, includeHeaders bool) {
+	writer := new(tabwriter.Writer)
+	writer.Init(out, 0, 0, 3, ' ', tabwriter.FilterHTML)
+	if includeHeaders {
+		fmt.Fprintln(writer, "KEY\tVALUE\tVERSION")
+	}
+	for _, entry := range l {
+		fmt.Fprintln(writer, fmt.Sprintf("%s\t%s\t%d", entry.Key, entry.Value, entry.Version))
+	}
+	writer.Flush()
+}
+
+func (l mapEntryList) keys() mapKeyList {
+	keys := make(mapKeyList, len(l))
+	for i, entry := range l {
+		keys[i] = entry.Key
+	}
+	return keys
+}
+
+// mapKeyList is the output representation of a list of map keys
+type mapKeyList []string
+
+func (l mapKeyList) writeTable(out io.Writer, includeHeaders bool) {
+	if includeHeaders {
+		fmt.Fprintln(out, "KEY")
+	}
+	for _, key := range l {
+		fmt.Fprintln(out, key)
 	}
-	return m
 }
 
 func newMapCreateCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:  "create",
 		Args: cobra.NoArgs,
-		Run:  runMapCreateCommand,
+		RunE: runMapCreateCommand,
 	}
 }
 
-func runMapCreateCommand(cmd *cobra.Command, _ []string) {
-	_map := newMapFromName(cmd)
-	_map.Close()
-	ExitWithOutput(fmt.Sprintf("Created %s", _map.Name().String()))
+func runMapCreateCommand(cmd *cobra.Command, _ []string) error {
+	_map, err := newMapFromName(cmd)
+	if err != nil {
+		return err
+	}
+	closePrimitive(_map)
+	return printResult(cmd, fmt.Sprintf("Created %s", _map.Name().String()))
 }
 
 func newMapDeleteCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:  "delete",
 		Args: cobra.NoArgs,
-		Run:  runMapDeleteCommand,
+		RunE: runMapDeleteCommand,
 	}
 }
 
-func runMapDeleteCommand(cmd *cobra.Command, _ []string) {
-	_map := newMapFromName(cmd)
-	err := _map.Delete()
+func runMapDeleteCommand(cmd *cobra.Command, _ []string) error {
+	_map, err := newMapFromName(cmd)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		ExitWithOutput(fmt.Sprintf("Deleted %s", _map.Name().String()))
+		return err
+	}
+	if err := deletePrimitive(_map); err != nil {
+		return err
 	}
+	return printResult(cmd, fmt.Sprintf("Deleted %s", _map.Name().String()))
 }
 
 func newMapGetCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:  "get",
 		Args: cobra.NoArgs,
-		Run:  runMapGetCommand,
+		RunE: withRetry(runMapGetCommand),
 	}
 	cmd.Flags().StringP("key", "k", "", "the key to get")
 	cmd.MarkFlagRequired("key")
+	addEncodingFlag(cmd)
+	addRawFlag(cmd)
 	return cmd
 }
 
-func runMapGetCommand(cmd *cobra.Command, _ []string) {
-	_map := newMapFromName(cmd)
+func runMapGetCommand(cmd *cobra.Command, _ []string) error {
+	encoding, err := getEncoding(cmd)
+	if err != nil {
+		return err
+	}
+	_map, err := newMapFromName(cmd)
+	if err != nil {
+		return err
+	}
 	key, _ := cmd.Flags().GetString("key")
-	value, err := _map.Get(newTimeoutContext(cmd), key)
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	value, err := _map.Get(ctx, key)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else if value != nil {
-		ExitWithOutput(value.String())
-	} else {
-		ExitWithOutput(nil)
+		return err
+	} else if value == nil {
+		return printValue(cmd, nil, nil)
 	}
+	return printValue(cmd, value.Value, newMapEntry(value, encoding))
 }
 
 func newMapPutCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:  "put",
 		Args: cobra.NoArgs,
-		Run:  runMapPutCommand,
+		RunE: runMapPutCommand,
 	}
 	cmd.Flags().StringP("key", "k", "", "the key to put")
 	cmd.MarkFlagRequired("key")
-	cmd.Flags().StringP("value", "v", "", "the value to put into the map")
-	cmd.MarkFlagRequired("value")
+	addValueFlags(cmd, "the value to put into the map")
 	cmd.Flags().Int64("version", 0, "the entry version")
+	cmd.Flags().Bool("if-absent", false, "only put the value if the key is not set")
 	return cmd
 }
 
-func runMapPutCommand(cmd *cobra.Command, _ []string) {
-	m := newMapFromName(cmd)
+func runMapPutCommand(cmd *cobra.Command, _ []string) error {
+	value, err := getValue(cmd)
+	if err != nil {
+		return err
+	}
+	encoding, _ := getEncoding(cmd)
+	m, err := newMapFromName(cmd)
+	if err != nil {
+		return err
+	}
 	key, _ := cmd.Flags().GetString("key")
-	value, _ := cmd.Flags().GetString("value")
 	version, _ := cmd.Flags().GetInt64("version")
+	ifAbsent, _ := cmd.Flags().GetBool("if-absent")
 	opts := []_map.PutOption{}
-	if version > 0 {
+	if version > 0 && ifAbsent {
+		return newExitError(ExitInvalidInput, errors.New("--version and --if-absent cannot be used together"))
+	} else if version > 0 {
 		opts = append(opts, _map.IfVersion(version))
+	} else if ifAbsent {
+		opts = append(opts, _map.IfNotSet())
 	}
 
-	kv, err := m.Put(newTimeoutContext(cmd), key, []byte(value), opts...)
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	kv, err := m.Put(ctx, key, value, opts...)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else if kv != nil {
-		ExitWithOutput(kv.String())
-	} else {
-		ExitWithOutput(nil)
+		if ifAbsent && getErrorCode(err) == codes.FailedPrecondition {
+			return newExitError(ExitConditionFailed, fmt.Errorf("key %s is already set", key))
+		}
+		return err
+	} else if kv == nil {
+		return printResult(cmd, nil)
 	}
+	return printResult(cmd, newMapEntry(kv, encoding))
+}
+
+func newMapReplaceCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replace",
+		Short: "Replace the value of an existing key",
+		Args:  cobra.NoArgs,
+		RunE:  runMapReplaceCommand,
+	}
+	cmd.Flags().StringP("key", "k", "", "the key to replace")
+	cmd.MarkFlagRequired("key")
+	addValueFlags(cmd, "the value with which to replace the current value")
+	cmd.Flags().String("expect-value", "", "only replace the value if the current value matches")
+	return cmd
+}
+
+func runMapReplaceCommand(cmd *cobra.Command, _ []string) error {
+	value, err := getValue(cmd)
+	if err != nil {
+		return err
+	}
+	encoding, _ := getEncoding(cmd)
+	var expect []byte
+	if cmd.Flags().Changed("expect-value") {
+		s, _ := cmd.Flags().GetString("expect-value")
+		if expect, err = decodeValue([]byte(s), encoding); err != nil {
+			return err
+		}
+	}
+	key, _ := cmd.Flags().GetString("key")
+	return replaceMapValue(cmd, key, expect, value, encoding)
+}
+
+func newMapCASCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cas",
+		Short: "Compare the value of a key and set it if it matches",
+		Args:  cobra.NoArgs,
+		RunE:  runMapCASCommand,
+	}
+	cmd.Flags().StringP("key", "k", "", "the key to update")
+	cmd.MarkFlagRequired("key")
+	cmd.Flags().String("old", "", "the expected current value")
+	cmd.MarkFlagRequired("old")
+	cmd.Flags().String("new", "", "the value to set")
+	cmd.MarkFlagRequired("new")
+	addEncodingFlag(cmd)
+	return cmd
+}
+
+func runMapCASCommand(cmd *cobra.Command, _ []string) error {
+	encoding, err := getEncoding(cmd)
+	if err != nil {
+		return err
+	}
+	oldValue, _ := cmd.Flags().GetString("old")
+	expect, err := decodeValue([]byte(oldValue), encoding)
+	if err != nil {
+		return err
+	}
+	newValue, _ := cmd.Flags().GetString("new")
+	value, err := decodeValue([]byte(newValue), encoding)
+	if err != nil {
+		return err
+	}
+	key, _ := cmd.Flags().GetString("key")
+	return replaceMapValue(cmd, key, expect, value, encoding)
+}
+
+// replaceMapValue sets the value of an existing key if its current value matches the expected value
+// If the expected value is nil, any current value is replaced. The update is conditional on the version
+// of the entry that was read, so a concurrent update also fails the condition.
+func replaceMapValue(cmd *cobra.Command, key string, expect []byte, value []byte, encoding string) error {
+	m, err := newMapFromName(cmd)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	kv, err := m.Get(ctx, key)
+	if err != nil {
+		return err
+	} else if kv == nil {
+		return newExitError(ExitConditionFailed, fmt.Errorf("key %s is not set", key))
+	} else if expect != nil && !bytes.Equal(kv.Value, expect) {
+		return newExitError(ExitConditionFailed, fmt.Errorf("value of key %s does not match the expected value", key))
+	}
+
+	kv, err = m.Put(ctx, key, value, _map.IfVersion(kv.Version))
+	if err != nil {
+		if getErrorCode(err) == codes.FailedPrecondition {
+			return newExitError(ExitConditionFailed, fmt.Errorf("key %s was modified concurrently", key))
+		}
+		return err
+	}
+	return printResult(cmd, newMapEntry(kv, encoding))
 }
 
 func newMapRemoveCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:  "remove",
 		Args: cobra.NoArgs,
-		Run:  runMapRemoveCommand,
+		RunE: runMapRemoveCommand,
 	}
 	cmd.Flags().StringP("key", "k", "", "the key to remove")
 	cmd.MarkFlagRequired("key")
 	cmd.Flags().Int64("version", 0, "the entry version")
+	addEncodingFlag(cmd)
 	return cmd
 }
 
-func runMapRemoveCommand(cmd *cobra.Command, _ []string) {
-	m := newMapFromName(cmd)
+func runMapRemoveCommand(cmd *cobra.Command, _ []string) error {
+	encoding, err := getEncoding(cmd)
+	if err != nil {
+		return err
+	}
+	m, err := newMapFromName(cmd)
+	if err != nil {
+		return err
+	}
 	key, _ := cmd.Flags().GetString("key")
 	version, _ := cmd.Flags().GetInt64("version")
 	opts := []_map.RemoveOption{}
@@ -164,68 +376,173 @@ func runMapRemoveCommand(cmd *cobra.Command, _ []string) {
 		opts = append(opts, _map.IfVersion(version))
 	}
 
-	value, err := m.Remove(newTimeoutContext(cmd), key, opts...)
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	value, err := m.Remove(ctx, key, opts...)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else if value != nil {
-		ExitWithOutput(value.String())
-	} else {
-		ExitWithOutput(nil)
+		return err
+	} else if value == nil {
+		return printResult(cmd, nil)
 	}
+	return printResult(cmd, newMapEntry(value, encoding))
+}
+
+func newMapEntriesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "entries",
+		Short: "List the entries in the map",
+		Args:  cobra.NoArgs,
+		RunE:  withRetry(runMapEntriesCommand),
+	}
+	addMapEntriesFlags(cmd)
+	cmd.Flags().Bool("keys-only", false, "list only the keys in the map")
+	addEncodingFlag(cmd)
+	return cmd
 }
 
 func newMapKeysCommand() *cobra.Command {
-	return &cobra.Command{
-		Use:  "keys",
-		Args: cobra.NoArgs,
-		Run:  runMapKeysCommand,
+	cmd := &cobra.Command{
+		Use:   "keys",
+		Short: "List the keys in the map",
+		Args:  cobra.NoArgs,
+		RunE:  withRetry(runMapKeysCommand),
 	}
+	addMapEntriesFlags(cmd)
+	return cmd
 }
 
-func runMapKeysCommand(cmd *cobra.Command, _ []string) {
-	m := newMapFromName(cmd)
-	ch := make(chan *_map.Entry)
-	err := m.Entries(context.TODO(), ch)
+func addMapEntriesFlags(cmd *cobra.Command) {
+	cmd.Flags().String("prefix", "", "only list keys with the given prefix")
+	cmd.Flags().String("regex", "", "only list keys matching the given regular expression")
+	cmd.Flags().Int("limit", 0, "the maximum number of entries to list, starting from the lowest key")
+	cmd.Flags().Bool("no-headers", false, "exclude headers from the output")
+}
+
+func runMapEntriesCommand(cmd *cobra.Command, _ []string) error {
+	entries, err := listMapEntries(cmd)
+	if err != nil {
+		return err
+	}
+	if keysOnly, _ := cmd.Flags().GetBool("keys-only"); keysOnly {
+		return printResult(cmd, entries.keys())
+	}
+	return printResult(cmd, entries)
+}
+
+func runMapKeysCommand(cmd *cobra.Command, _ []string) error {
+	entries, err := listMapEntries(cmd)
 	if err != nil {
-		ExitWithError(ExitError, err)
+		return err
 	}
+	return printResult(cmd, entries.keys())
+}
+
+// listMapEntries lists the entries in the map named by the --name flag
+func listMapEntries(cmd *cobra.Command) (mapEntryList, error) {
+	name, _ := cmd.Flags().GetString("name")
+	return listMapEntriesByName(cmd, name)
+}
+
+// listMapEntriesByName lists the entries in the named map matching the --prefix and --regex flags, up to --limit entries
+// Entries are streamed from all partitions in no particular order, so a limit keeps the entries with the lowest
+// keys, holding at most twice the limit in memory. The returned entries are sorted by key.
+func listMapEntriesByName(cmd *cobra.Command, name string) (mapEntryList, error) {
+	filter, err := newKeyFilter(cmd)
+	if err != nil {
+		return nil, err
+	}
+	encoding, err := getEncoding(cmd)
+	if err != nil {
+		return nil, err
+	}
+	limit, _ := cmd.Flags().GetInt("limit")
+	if limit < 0 {
+		return nil, newExitError(ExitInvalidInput, fmt.Errorf("invalid limit %d", limit))
+	}
+
+	m, err := newMapFromNameString(cmd, name)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	ch := make(chan *_map.Entry)
+	if err := m.Entries(ctx, ch); err != nil {
+		return nil, err
+	}
+
+	entries := mapEntryList{}
 	for kv := range ch {
-		println(fmt.Sprintf("%v", kv))
+		if !filter.matches(kv.Key) {
+			continue
+		}
+		entries = append(entries, newMapEntry(kv, encoding))
+		if limit > 0 && len(entries) >= 2*limit {
+			entries = truncateMapEntries(entries, limit)
+		}
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, ctx.Err()
+	}
+	if limit > 0 {
+		return truncateMapEntries(entries, limit), nil
 	}
+	sortMapEntries(entries)
+	return entries, nil
+}
+
+// sortMapEntries sorts the given entries by key
+func sortMapEntries(entries mapEntryList) {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+}
+
+// truncateMapEntries sorts the given entries by key and returns at most limit entries with the lowest keys
+func truncateMapEntries(entries mapEntryList, limit int) mapEntryList {
+	sortMapEntries(entries)
+	if len(entries) > limit {
+		return entries[:limit]
+	}
+	return entries
 }
 
 func newMapSizeCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:  "size",
 		Args: cobra.NoArgs,
-		Run:  runMapSizeCommand,
+		RunE: withRetry(runMapSizeCommand),
 	}
 }
 
-func runMapSizeCommand(cmd *cobra.Command, _ []string) {
-	_map := newMapFromName(cmd)
-	size, err := _map.Len(newTimeoutContext(cmd))
+func runMapSizeCommand(cmd *cobra.Command, _ []string) error {
+	_map, err := newMapFromName(cmd)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	size, err := _map.Len(ctx)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		ExitWithOutput(size)
+		return err
 	}
+	return printResult(cmd, size)
 }
 
 func newMapClearCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:  "clear",
 		Args: cobra.NoArgs,
-		Run:  runMapClearCommand,
+		RunE: runMapClearCommand,
 	}
 }
 
-func runMapClearCommand(cmd *cobra.Command, _ []string) {
-	_map := newMapFromName(cmd)
-	err := _map.Clear(newTimeoutContext(cmd))
+func runMapClearCommand(cmd *cobra.Command, _ []string) error {
+	_map, err := newMapFromName(cmd)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		ExitWithSuccess()
+		return err
 	}
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	return _map.Clear(ctx)
 }
@@ -0,0 +1,293 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"github.com/atomix/go-client/pkg/client/map"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	diffAdd    = "add"
+	diffRemove = "remove"
+	diffChange = "change"
+)
+
+func newMapDiffCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Compare the map with another map or a file",
+		Long: `Compare the map with another map or a file
+
+With --against, the map named by --name is the source and the map named by --against is the
+target. The target may be in another partition group or controller when given as a primitive
+URI, or in another configuration context with --against-context. With --file, the file written
+by map export is the source and the map named by --name is the target.
+
+Flags and environment variables take precedence over contexts, so the controller, namespace,
+group, application, TLS and token settings cannot be set by them with --against-context. Select
+the source environment with --context instead.
+
+The changes required to make the target match the source are printed in a format similar to a
+unified diff, and are written to the target with --apply. Changes are applied only if the
+target entries have not been modified since they were compared.`,
+		Args: cobra.NoArgs,
+		RunE: runMapDiffCommand,
+	}
+	cmd.Flags().String("against", "", "the name of the target map")
+	cmd.Flags().String("against-context", "", "the configuration context of the target map")
+	cmd.Flags().StringP("file", "f", "", "a file containing the source entries, or - for stdin")
+	cmd.Flags().String("format", "", "the file format (json, ndjson, csv); defaults to the file extension")
+	cmd.Flags().String("prefix", "", "only compare keys with the given prefix")
+	cmd.Flags().String("regex", "", "only compare keys matching the given regular expression")
+	cmd.Flags().Bool("apply", false, "apply the changes to the target map")
+	cmd.Flags().Bool("no-headers", false, "exclude headers from the output")
+	addEncodingFlag(cmd)
+	return cmd
+}
+
+// mapDiff is the output representation of a difference between two maps
+type mapDiff struct {
+	Key     string  `json:"key" yaml:"key"`
+	Action  string  `json:"action" yaml:"action"`
+	Source  *string `json:"source,omitempty" yaml:"source,omitempty"`
+	Target  *string `json:"target,omitempty" yaml:"target,omitempty"`
+	version int64
+}
+
+// mapDiffResult is the output representation of the differences between two maps
+type mapDiffResult struct {
+	Source  string     `json:"source" yaml:"source"`
+	Target  string     `json:"target" yaml:"target"`
+	Changes []*mapDiff `json:"changes" yaml:"changes"`
+	Applied bool       `json:"applied" yaml:"applied"`
+}
+
+func (r *mapDiffResult) writeTable(out io.Writer, includeHeaders bool) {
+	if includeHeaders && len(r.Changes) > 0 {
+		fmt.Fprintln(out, fmt.Sprintf("--- %s", r.Target))
+		fmt.Fprintln(out, fmt.Sprintf("+++ %s", r.Source))
+	}
+	for _, diff := range r.Changes {
+		if diff.Target != nil {
+			fmt.Fprintln(out, fmt.Sprintf("-%s: %s", diff.Key, *diff.Target))
+		}
+		if diff.Source != nil {
+			fmt.Fprintln(out, fmt.Sprintf("+%s: %s", diff.Key, *diff.Source))
+		}
+	}
+	if r.Applied {
+		fmt.Fprintln(out, fmt.Sprintf("Applied %d changes to %s", len(r.Changes), r.Target))
+	}
+}
+
+func runMapDiffCommand(cmd *cobra.Command, _ []string) error {
+	name, _ := cmd.Flags().GetString("name")
+	against, _ := cmd.Flags().GetString("against")
+	againstContext, _ := cmd.Flags().GetString("against-context")
+	file, _ := cmd.Flags().GetString("file")
+	apply, _ := cmd.Flags().GetBool("apply")
+	if (against == "") == (file == "") {
+		return newExitError(ExitInvalidInput, errors.New("exactly one of --against or --file is required"))
+	} else if againstContext != "" && against == "" {
+		return newExitError(ExitInvalidInput, errors.New("--against-context requires --against"))
+	} else if againstContext != "" {
+		if err := checkAgainstContext(); err != nil {
+			return err
+		}
+	}
+	encoding, err := getEncoding(cmd)
+	if err != nil {
+		return err
+	}
+
+	result := &mapDiffResult{}
+	target := name
+	var source mapEntryList
+	if file != "" {
+		result.Source = file
+		source, err = readMapDiffFile(cmd, file)
+	} else {
+		result.Source = name
+		target = against
+		source, err = listMapEntriesByName(cmd, name)
+	}
+	if err != nil {
+		return err
+	}
+	result.Target = target
+
+	diff := func() error {
+		entries, err := listMapEntriesByName(cmd, target)
+		if err != nil {
+			return err
+		}
+		result.Changes = diffMapEntries(source, entries)
+		if apply && len(result.Changes) > 0 {
+			if err := applyMapDiff(cmd, target, result.Changes, encoding); err != nil {
+				return err
+			}
+			result.Applied = true
+		}
+		return nil
+	}
+
+	// The target map is resolved in the target context for both comparing and applying changes
+	if againstContext != "" {
+		result.Target = fmt.Sprintf("%s (context %s)", target, againstContext)
+		err = withContext(againstContext, diff)
+	} else {
+		err = diff()
+	}
+	if err != nil {
+		return err
+	}
+	return printResult(cmd, result)
+}
+
+// againstContextKeys are the settings that select and authenticate the target of a diff
+var againstContextKeys = []string{"controller", "namespace", "group", "app", tlsKey, tlsCACertKey, tlsCertKey, tlsKeyKey,
+	tlsInsecureSkipVerify, tokenKey, tokenFileKey, tokenCommandKey}
+
+// checkAgainstContext returns an error if a setting of the target context is overridden by a flag or environment variable
+// Flags and environment variables take precedence over contexts, so they would otherwise silently apply the
+// source environment's settings to the target.
+func checkAgainstContext() error {
+	overrides := []string{}
+	for _, key := range againstContextKeys {
+		if flag := getConfigFlag(key); flag != nil {
+			overrides = append(overrides, "--"+flag.Name)
+		} else if isEnvSet(key) {
+			overrides = append(overrides, getConfigEnv(key))
+		}
+	}
+	if len(overrides) > 0 {
+		return newExitError(ExitInvalidInput, fmt.Errorf("%s cannot be used with --against-context; select the source with --context instead",
+			strings.Join(overrides, ", ")))
+	}
+	return nil
+}
+
+// readMapDiffFile reads the source entries for a diff from a file in the format written by map export
+func readMapDiffFile(cmd *cobra.Command, file string) (mapEntryList, error) {
+	format, err := getEntryFormat(cmd, file)
+	if err != nil {
+		return nil, err
+	}
+	reader := input
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		reader = f
+	}
+	entries, err := readMapEntries(reader, format)
+	if err != nil {
+		return nil, newExitError(ExitInvalidInput, fmt.Errorf("invalid %s file %s: %s", format, file, err))
+	}
+
+	filter, err := newKeyFilter(cmd)
+	if err != nil {
+		return nil, err
+	}
+	filtered := mapEntryList{}
+	for _, entry := range entries {
+		if filter.matches(entry.Key) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered, nil
+}
+
+// diffMapEntries returns the changes required to make the target entries match the source entries
+// The changes are ordered by key.
+func diffMapEntries(source, target mapEntryList) []*mapDiff {
+	targetEntries := make(map[string]*mapEntry)
+	for _, entry := range target {
+		targetEntries[entry.Key] = entry
+	}
+	sourceEntries := make(map[string]*mapEntry)
+	for _, entry := range source {
+		sourceEntries[entry.Key] = entry
+	}
+
+	keys := make([]string, 0, len(sourceEntries)+len(targetEntries))
+	for key := range sourceEntries {
+		keys = append(keys, key)
+	}
+	for key := range targetEntries {
+		if _, ok := sourceEntries[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	changes := []*mapDiff{}
+	for _, key := range keys {
+		s, inSource := sourceEntries[key]
+		t, inTarget := targetEntries[key]
+		switch {
+		case inSource && !inTarget:
+			changes = append(changes, &mapDiff{Key: key, Action: diffAdd, Source: &s.Value})
+		case !inSource && inTarget:
+			changes = append(changes, &mapDiff{Key: key, Action: diffRemove, Target: &t.Value, version: t.Version})
+		case s.Value != t.Value:
+			changes = append(changes, &mapDiff{Key: key, Action: diffChange, Source: &s.Value, Target: &t.Value, version: t.Version})
+		}
+	}
+	return changes
+}
+
+// applyMapDiff writes the changes to the named map
+// Each change is conditional on the target entry being unchanged since it was compared.
+func applyMapDiff(cmd *cobra.Command, name string, changes []*mapDiff, encoding string) error {
+	m, err := newMapFromNameString(cmd, name)
+	if err != nil {
+		return err
+	}
+	defer closePrimitive(m)
+
+	for _, change := range changes {
+		ctx, cancel := newTimeoutContext(cmd)
+		switch change.Action {
+		case diffRemove:
+			_, err = m.Remove(ctx, change.Key, _map.IfVersion(change.version))
+		default:
+			var value []byte
+			value, err = decodeValue([]byte(*change.Source), encoding)
+			if err != nil {
+				break
+			}
+			opt := _map.PutOption(_map.IfNotSet())
+			if change.Action == diffChange {
+				opt = _map.IfVersion(change.version)
+			}
+			_, err = m.Put(ctx, change.Key, value, opt)
+		}
+		cancel()
+		if err != nil {
+			return newExitError(GetExitCode(err), fmt.Errorf("failed to apply change to key %s: %s", change.Key, getErrorMessage(err)))
+		}
+	}
+	return nil
+}
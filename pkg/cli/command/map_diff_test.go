@@ -0,0 +1,145 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDiffMapEntries(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  mapEntryList
+		target  mapEntryList
+		changes string
+	}{
+		{
+			name:    "equal",
+			source:  mapEntryList{{Key: "a", Value: "1"}},
+			target:  mapEntryList{{Key: "a", Value: "1", Version: 1}},
+			changes: "",
+		},
+		{
+			name:    "empty target",
+			source:  mapEntryList{{Key: "b", Value: "2"}, {Key: "a", Value: "1"}},
+			changes: "add a 0, add b 0",
+		},
+		{
+			name:    "empty source",
+			target:  mapEntryList{{Key: "a", Value: "1", Version: 3}},
+			changes: "remove a 3",
+		},
+		{
+			name:    "changed value",
+			source:  mapEntryList{{Key: "a", Value: "2"}},
+			target:  mapEntryList{{Key: "a", Value: "1", Version: 5}},
+			changes: "change a 5",
+		},
+		{
+			name:    "mixed changes ordered by key",
+			source:  mapEntryList{{Key: "d", Value: "4"}, {Key: "b", Value: "2"}, {Key: "a", Value: "1"}},
+			target:  mapEntryList{{Key: "c", Value: "3", Version: 2}, {Key: "b", Value: "1", Version: 4}, {Key: "a", Value: "1", Version: 1}},
+			changes: "change b 4, remove c 2, add d 0",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			changes := []string{}
+			for _, change := range diffMapEntries(test.source, test.target) {
+				changes = append(changes, fmt.Sprintf("%s %s %d", change.Action, change.Key, change.version))
+			}
+			if actual := strings.Join(changes, ", "); actual != test.changes {
+				t.Errorf("expected changes %q, got %q", test.changes, actual)
+			}
+		})
+	}
+}
+
+func TestMapDiffAgainstContext(t *testing.T) {
+	controller := startTestController(t)
+	defer controller.Stop()
+
+	setup := [][]string{
+		{"config", "set-context", "source", "--controller", controller.Address()},
+		{"config", "set-context", "target", "--controller", controller.Address(), "--app", "target"},
+		{"--context", "source", "map", "put", "--name", "test", "--key", "a", "--value", "1"},
+		{"--context", "source", "map", "put", "--name", "test", "--key", "b", "--value", "2"},
+		{"--context", "target", "map", "put", "--name", "test", "--key", "b", "--value", "3"},
+		{"--context", "target", "map", "put", "--name", "test", "--key", "c", "--value", "4"},
+	}
+	for _, args := range setup {
+		if _, _, err := executeCommand(args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	diff := []string{"--context", "source", "map", "diff", "--name", "test", "--against", "test", "--against-context", "target",
+		"-o", "go-template={{range .changes}}{{.action}}:{{.key}} {{end}}"}
+	tests := []struct {
+		name   string
+		args   []string
+		env    string
+		output string
+		code   int
+	}{
+		{
+			name: "controller flag",
+			args: append([]string{"--controller", controller.Address()}, diff...),
+			code: ExitInvalidInput,
+		},
+		{
+			name: "token flag",
+			args: append([]string{"--token", "secret"}, diff...),
+			code: ExitInvalidInput,
+		},
+		{
+			name: "token environment variable",
+			args: diff,
+			env:  envPrefix + "_TOKEN",
+			code: ExitInvalidInput,
+		},
+		{
+			name:   "diff",
+			args:   diff,
+			output: "add:a change:b remove:c ",
+		},
+		{
+			name:   "apply",
+			args:   append(diff, "--apply"),
+			output: "add:a change:b remove:c ",
+		},
+		{
+			name:   "applied",
+			args:   diff,
+			output: "",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.env != "" {
+				t.Setenv(test.env, "secret")
+			}
+			output, _, err := executeCommand(test.args...)
+			if code := getTestExitCode(err); code != test.code {
+				t.Fatalf("expected exit code %d, got %d (%v)", test.code, code, err)
+			}
+			if output != test.output {
+				t.Errorf("expected output %q, got %q", test.output, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/atomix/go-client/pkg/client/map"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"os/exec"
+)
+
+const (
+	validateJSON = "json"
+	validateYAML = "yaml"
+)
+
+const defaultEditor = "vi"
+
+func newMapEditCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "edit",
+		Short: "Edit the value of a key in an editor",
+		Long: `Edit the value of a key in an editor
+
+The value is opened in the editor set by $VISUAL or $EDITOR and written back when the editor
+exits, only if the entry has not changed since it was read. If the entry was modified in the
+meantime, the editor is reopened with the current value. Keys that are not set are created.
+Exiting the editor without changes cancels the edit.`,
+		Args: cobra.NoArgs,
+		RunE: runMapEditCommand,
+	}
+	cmd.Flags().StringP("key", "k", "", "the key to edit")
+	cmd.MarkFlagRequired("key")
+	cmd.Flags().String("validate", "", "validate the edited value as json or yaml before writing it")
+	addEncodingFlag(cmd)
+	return cmd
+}
+
+func runMapEditCommand(cmd *cobra.Command, _ []string) error {
+	key, _ := cmd.Flags().GetString("key")
+	validate, _ := cmd.Flags().GetString("validate")
+	switch validate {
+	case "", validateJSON, validateYAML:
+	default:
+		return newExitError(ExitInvalidInput, fmt.Errorf("invalid validation format %s", validate))
+	}
+	encoding, err := getEncoding(cmd)
+	if err != nil {
+		return err
+	}
+	m, err := newMapFromName(cmd)
+	if err != nil {
+		return err
+	}
+
+	for {
+		ctx, cancel := newTimeoutContext(cmd)
+		kv, err := m.Get(ctx, key)
+		cancel()
+		if err != nil {
+			return err
+		}
+		var version int64
+		var original []byte
+		if kv != nil {
+			version = kv.Version
+			original = []byte(encodeValue(kv.Value, encoding))
+		}
+
+		edited, err := editValue(cmd, original, validate)
+		if err != nil {
+			return err
+		} else if bytes.Equal(edited, original) {
+			return printResult(cmd, "Edit cancelled, no changes made")
+		}
+		value, err := decodeValue(edited, encoding)
+		if err != nil {
+			return err
+		}
+
+		opts := []_map.PutOption{_map.IfNotSet()}
+		if version > 0 {
+			opts = []_map.PutOption{_map.IfVersion(version)}
+		}
+		ctx, cancel = newTimeoutContext(cmd)
+		kv, err = m.Put(ctx, key, value, opts...)
+		cancel()
+		if err == nil {
+			return printResult(cmd, newMapEntry(kv, encoding))
+		} else if getErrorCode(err) != codes.FailedPrecondition {
+			return err
+		}
+		fmt.Fprintf(errOutput, "Key %s was modified while editing; reopening the editor with the current value\n", key)
+	}
+}
+
+// editValue opens the value in the editor until it is left unchanged or is valid in the given format
+func editValue(cmd *cobra.Command, value []byte, format string) ([]byte, error) {
+	file, err := ioutil.TempFile("", "atomix-edit-*."+getEditExtension(format))
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(value); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+
+	previous := value
+	for {
+		if err := runEditor(file.Name()); err != nil {
+			return nil, err
+		}
+		edited, err := ioutil.ReadFile(file.Name())
+		if err != nil {
+			return nil, err
+		}
+		// Most editors terminate the file with a newline, which should not change the value
+		if !bytes.HasSuffix(value, []byte("\n")) {
+			edited = bytes.TrimSuffix(edited, []byte("\n"))
+		}
+		if bytes.Equal(edited, value) {
+			return edited, nil
+		}
+		err = validateValue(edited, format)
+		if err == nil {
+			return edited, nil
+		} else if bytes.Equal(edited, previous) {
+			return nil, newExitError(ExitInvalidInput, err)
+		}
+		// Reopen the editor so the value can be corrected; saving it unchanged again aborts the edit
+		fmt.Fprintf(errOutput, "Error: %s; reopening the editor\n", err)
+		previous = edited
+	}
+}
+
+func getEditExtension(format string) string {
+	if format == "" {
+		return "txt"
+	}
+	return format
+}
+
+// runEditor runs the editor set by $VISUAL or $EDITOR on the given file
+// The editor is run through the shell so the variable may include arguments. It is connected to the
+// terminal rather than the command input and output, which may be redirected.
+func runEditor(file string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = defaultEditor
+	}
+	command := exec.Command("/bin/sh", "-c", editor+` "$1"`, "sh", file)
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	if err := command.Run(); err != nil {
+		return fmt.Errorf("editor %s failed: %s", editor, err)
+	}
+	return nil
+}
+
+// validateValue verifies that the value is valid in the given format
+func validateValue(value []byte, format string) error {
+	var v interface{}
+	switch format {
+	case validateJSON:
+		if err := json.Unmarshal(value, &v); err != nil {
+			return fmt.Errorf("invalid JSON: %s", err)
+		}
+	case validateYAML:
+		if err := yaml.Unmarshal(value, &v); err != nil {
+			return fmt.Errorf("invalid YAML: %s", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,355 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/atomix/go-client/pkg/client/map"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	entryFormatJSON   = "json"
+	entryFormatNDJSON = "ndjson"
+	entryFormatCSV    = "csv"
+)
+
+const (
+	conflictOverwrite = "overwrite"
+	conflictSkip      = "skip"
+	conflictFail      = "fail"
+)
+
+const (
+	importInsert    = "insert"
+	importUpdate    = "update"
+	importSkip      = "skip"
+	importUnchanged = "unchanged"
+)
+
+func newMapExportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the entries in the map to a file",
+		Args:  cobra.NoArgs,
+		RunE:  withRetry(runMapExportCommand),
+	}
+	cmd.Flags().StringP("file", "f", "-", "the file to which to export entries, or - for stdout")
+	cmd.Flags().String("format", "", "the file format (json, ndjson, csv); defaults to the file extension")
+	cmd.Flags().String("prefix", "", "only export keys with the given prefix")
+	cmd.Flags().String("regex", "", "only export keys matching the given regular expression")
+	addEncodingFlag(cmd)
+	return cmd
+}
+
+func runMapExportCommand(cmd *cobra.Command, _ []string) error {
+	file, _ := cmd.Flags().GetString("file")
+	format, err := getEntryFormat(cmd, file)
+	if err != nil {
+		return err
+	}
+	entries, err := listMapEntries(cmd)
+	if err != nil {
+		return err
+	}
+
+	if file == "-" {
+		return writeMapEntries(cmd.OutOrStdout(), format, entries)
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := writeMapEntries(f, format, entries); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return printResult(cmd, fmt.Sprintf("Exported %d entries to %s", len(entries), file))
+}
+
+func newMapImportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import entries into the map from a file",
+		Long: `Import entries into the map from a file
+
+Entries are read from a JSON, NDJSON or CSV file in the format written by map export. Entry
+versions in the file are ignored. Keys that already exist with a different value are conflicts,
+which are overwritten, skipped or cause the import to fail before any entry is written depending
+on --on-conflict.`,
+		Args: cobra.NoArgs,
+		RunE: runMapImportCommand,
+	}
+	cmd.Flags().StringP("file", "f", "-", "the file from which to import entries, or - for stdin")
+	cmd.Flags().String("format", "", "the file format (json, ndjson, csv); defaults to the file extension")
+	cmd.Flags().String("on-conflict", conflictOverwrite, "how to handle existing keys (overwrite, skip, fail)")
+	cmd.Flags().Bool("dry-run", false, "report the changes without writing them")
+	addEncodingFlag(cmd)
+	cmd.Flags().Bool("no-headers", false, "exclude headers from the output")
+	return cmd
+}
+
+// mapImportChange is the output representation of a change made by an import
+type mapImportChange struct {
+	Key    string `json:"key" yaml:"key"`
+	Action string `json:"action" yaml:"action"`
+	Value  string `json:"value" yaml:"value"`
+}
+
+// mapImportChangeList is the output representation of the changes made by an import
+type mapImportChangeList []*mapImportChange
+
+func (l mapImportChangeList) writeTable(out io.Writer, includeHeaders bool) {
+	writer := new(tabwriter.Writer)
+	writer.Init(out, 0, 0, 3, ' ', tabwriter.FilterHTML)
+	if includeHeaders {
+		fmt.Fprintln(writer, "KEY\tACTION\tVALUE")
+	}
+	for _, change := range l {
+		fmt.Fprintln(writer, fmt.Sprintf("%s\t%s\t%s", change.Key, change.Action, change.Value))
+	}
+	writer.Flush()
+}
+
+func runMapImportCommand(cmd *cobra.Command, _ []string) error {
+	file, _ := cmd.Flags().GetString("file")
+	onConflict, _ := cmd.Flags().GetString("on-conflict")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	switch onConflict {
+	case conflictOverwrite, conflictSkip, conflictFail:
+	default:
+		return newExitError(ExitInvalidInput, fmt.Errorf("invalid conflict mode %s", onConflict))
+	}
+	format, err := getEntryFormat(cmd, file)
+	if err != nil {
+		return err
+	}
+	encoding, err := getEncoding(cmd)
+	if err != nil {
+		return err
+	}
+
+	reader := input
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		reader = f
+	}
+	entries, err := readMapEntries(reader, format)
+	if err != nil {
+		return newExitError(ExitInvalidInput, fmt.Errorf("invalid %s file %s: %s", format, file, err))
+	}
+
+	m, err := newMapFromName(cmd)
+	if err != nil {
+		return err
+	}
+
+	// Plan all changes before writing so a conflict fails the import without modifying the map
+	changes := make(mapImportChangeList, len(entries))
+	values := make([][]byte, len(entries))
+	versions := make([]int64, len(entries))
+	for i, entry := range entries {
+		value, err := decodeValue([]byte(entry.Value), encoding)
+		if err != nil {
+			return err
+		}
+		values[i] = value
+
+		ctx, cancel := newTimeoutContext(cmd)
+		kv, err := m.Get(ctx, entry.Key)
+		cancel()
+		if err != nil {
+			return err
+		}
+
+		change := &mapImportChange{
+			Key:   entry.Key,
+			Value: entry.Value,
+		}
+		switch {
+		case kv == nil:
+			change.Action = importInsert
+		case bytes.Equal(kv.Value, value):
+			change.Action = importUnchanged
+		case onConflict == conflictFail:
+			return newExitError(ExitConditionFailed, fmt.Errorf("key %s already exists with a different value", entry.Key))
+		case onConflict == conflictSkip:
+			change.Action = importSkip
+		default:
+			change.Action = importUpdate
+			versions[i] = kv.Version
+		}
+		changes[i] = change
+	}
+
+	// Changes are only written if the entries were not modified since they were planned
+	if !dryRun {
+		for i, change := range changes {
+			var opt _map.PutOption
+			switch change.Action {
+			case importInsert:
+				opt = _map.IfNotSet()
+			case importUpdate:
+				opt = _map.IfVersion(versions[i])
+			default:
+				continue
+			}
+			ctx, cancel := newTimeoutContext(cmd)
+			_, err := m.Put(ctx, change.Key, values[i], opt)
+			cancel()
+			if err != nil {
+				if getErrorCode(err) == codes.FailedPrecondition {
+					return newExitError(ExitConditionFailed, fmt.Errorf("key %s was modified during the import", change.Key))
+				}
+				return err
+			}
+		}
+	}
+	return printResult(cmd, changes)
+}
+
+// getEntryFormat returns the entry file format set by --format or inferred from the file extension
+func getEntryFormat(cmd *cobra.Command, file string) (string, error) {
+	format, _ := cmd.Flags().GetString("format")
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".ndjson", ".jsonl":
+			format = entryFormatNDJSON
+		case ".csv":
+			format = entryFormatCSV
+		default:
+			format = entryFormatJSON
+		}
+	}
+	switch format {
+	case entryFormatJSON, entryFormatNDJSON, entryFormatCSV:
+		return format, nil
+	}
+	return "", newExitError(ExitInvalidInput, fmt.Errorf("invalid format %s", format))
+}
+
+// writeMapEntries writes the given entries in the given format
+func writeMapEntries(writer io.Writer, format string, entries mapEntryList) error {
+	switch format {
+	case entryFormatNDJSON:
+		encoder := json.NewEncoder(writer)
+		for _, entry := range entries {
+			if err := encoder.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	case entryFormatCSV:
+		w := csv.NewWriter(writer)
+		w.Write([]string{"key", "value", "version"})
+		for _, entry := range entries {
+			w.Write([]string{entry.Key, entry.Value, strconv.FormatInt(entry.Version, 10)})
+		}
+		w.Flush()
+		return w.Error()
+	default:
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(writer, string(data))
+		return err
+	}
+}
+
+// readMapEntries reads entries in the given format
+// Entries without a key or with a key that appears earlier in the file are rejected.
+func readMapEntries(reader io.Reader, format string) (mapEntryList, error) {
+	entries := mapEntryList{}
+	switch format {
+	case entryFormatNDJSON:
+		scanner := bufio.NewScanner(reader)
+		scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+		for line := 1; scanner.Scan(); line++ {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" {
+				continue
+			}
+			entry := &mapEntry{}
+			if err := json.Unmarshal([]byte(text), entry); err != nil {
+				return nil, fmt.Errorf("line %d: %s", line, err)
+			}
+			entries = append(entries, entry)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	case entryFormatCSV:
+		records, err := csv.NewReader(reader).ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return entries, nil
+		}
+		keyIndex, valueIndex := -1, -1
+		for i, column := range records[0] {
+			switch strings.ToLower(strings.TrimSpace(column)) {
+			case "key":
+				keyIndex = i
+			case "value":
+				valueIndex = i
+			}
+		}
+		if keyIndex == -1 || valueIndex == -1 {
+			return nil, fmt.Errorf("expected key and value columns")
+		}
+		for _, record := range records[1:] {
+			entries = append(entries, &mapEntry{
+				Key:   record[keyIndex],
+				Value: record[valueIndex],
+			})
+		}
+	default:
+		if err := json.NewDecoder(reader).Decode(&entries); err != nil {
+			return nil, err
+		}
+	}
+
+	keys := make(map[string]bool)
+	for i, entry := range entries {
+		if entry == nil || entry.Key == "" {
+			return nil, fmt.Errorf("entry %d has no key", i+1)
+		}
+		if keys[entry.Key] {
+			return nil, fmt.Errorf("entry %d has duplicate key %s", i+1, entry.Key)
+		}
+		keys[entry.Key] = true
+	}
+	return entries, nil
+}
@@ -0,0 +1,201 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/atomix/go-client/pkg/client/map"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+)
+
+// eventReplayed is the type displayed for existing entries replayed at the start of a watch
+const eventReplayed = "replayed"
+
+func newMapWatchCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Watch the map for changes",
+		Args:  cobra.NoArgs,
+		RunE:  runMapWatchCommand,
+	}
+	cmd.Flags().Bool("replay", false, "replay existing entries before watching for changes")
+	cmd.Flags().String("prefix", "", "only show events for keys with the given prefix")
+	cmd.Flags().String("regex", "", "only show events for keys matching the given regular expression")
+	cmd.Flags().Bool("no-headers", false, "exclude headers from the output")
+	addEncodingFlag(cmd)
+	return cmd
+}
+
+// mapEvent is the output representation of a map event
+type mapEvent struct {
+	Type    string `json:"type" yaml:"type"`
+	Key     string `json:"key" yaml:"key"`
+	Value   string `json:"value" yaml:"value"`
+	Version int64  `json:"version" yaml:"version"`
+}
+
+func newMapEvent(event *_map.Event, encoding string) *mapEvent {
+	t := string(event.Type)
+	if event.Type == _map.EventNone {
+		t = eventReplayed
+	}
+	return &mapEvent{
+		Type:    t,
+		Key:     event.Entry.Key,
+		Value:   encodeValue(event.Entry.Value, encoding),
+		Version: event.Entry.Version,
+	}
+}
+
+func (e *mapEvent) writeTable(writer io.Writer, _ bool) {
+	fmt.Fprintln(writer, fmt.Sprintf("%s\t%s\t%s\t%d", e.Type, e.Key, e.Value, e.Version))
+}
+
+// keyFilter matches keys against an optional prefix and regular expression
+type keyFilter struct {
+	prefix string
+	regex  *regexp.Regexp
+}
+
+func newKeyFilter(cmd *cobra.Command) (*keyFilter, error) {
+	prefix, _ := cmd.Flags().GetString("prefix")
+	filter := &keyFilter{prefix: prefix}
+	if expr, _ := cmd.Flags().GetString("regex"); expr != "" {
+		regex, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, newExitError(ExitInvalidInput, err)
+		}
+		filter.regex = regex
+	}
+	return filter, nil
+}
+
+func (f *keyFilter) matches(key string) bool {
+	if !strings.HasPrefix(key, f.prefix) {
+		return false
+	}
+	return f.regex == nil || f.regex.MatchString(key)
+}
+
+func runMapWatchCommand(cmd *cobra.Command, _ []string) error {
+	filter, err := newKeyFilter(cmd)
+	if err != nil {
+		return err
+	}
+	encoding, err := getEncoding(cmd)
+	if err != nil {
+		return err
+	}
+	m, err := newMapFromName(cmd)
+	if err != nil {
+		return err
+	}
+	defer closePrimitive(m)
+
+	opts := []_map.WatchOption{}
+	if replay, _ := cmd.Flags().GetBool("replay"); replay {
+		opts = append(opts, _map.WithReplay())
+	}
+
+	// The watch is only expected to end when interrupted; otherwise the event stream failed
+	ctx, cancel := context.WithCancel(withCredentials(context.Background()))
+	defer cancel()
+	interrupted := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		select {
+		case <-signals:
+			close(interrupted)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ch := make(chan *_map.Event)
+	if err := m.Watch(ctx, ch, opts...); err != nil {
+		return err
+	}
+
+	printer := newEventPrinter(cmd)
+	for event := range ch {
+		if !filter.matches(event.Entry.Key) {
+			continue
+		}
+		if err := printer.print(newMapEvent(event, encoding)); err != nil {
+			return err
+		}
+	}
+	select {
+	case <-interrupted:
+		return nil
+	default:
+		return newExitError(ExitBadConnection, fmt.Errorf("watch of %s ended unexpectedly", m.Name().Name))
+	}
+}
+
+// eventPrinter writes a stream of events to the command output
+// JSON events are written as newline delimited JSON. Table rows are padded to a minimum width
+// since each row is flushed as soon as it is received.
+type eventPrinter struct {
+	cmd     *cobra.Command
+	format  string
+	table   *tabwriter.Writer
+	headers bool
+}
+
+func newEventPrinter(cmd *cobra.Command) *eventPrinter {
+	noHeaders, _ := cmd.Flags().GetBool("no-headers")
+	table := new(tabwriter.Writer)
+	table.Init(cmd.OutOrStdout(), 10, 0, 3, ' ', tabwriter.FilterHTML)
+	return &eventPrinter{
+		cmd:     cmd,
+		format:  getOutputFormat(cmd),
+		table:   table,
+		headers: !noHeaders,
+	}
+}
+
+func (p *eventPrinter) print(event *mapEvent) error {
+	switch p.format {
+	case outputTable:
+		if p.headers {
+			fmt.Fprintln(p.table, "TYPE\tKEY\tVALUE\tVERSION")
+			p.headers = false
+		}
+		event.writeTable(p.table, false)
+		return p.table.Flush()
+	case outputJSON:
+		bytes, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(p.cmd.OutOrStdout(), string(bytes))
+		return nil
+	case outputYAML:
+		fmt.Fprintln(p.cmd.OutOrStdout(), "---")
+	}
+	return printResult(p.cmd, event)
+}
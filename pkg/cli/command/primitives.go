@@ -19,7 +19,7 @@ import (
 	"github.com/atomix/api/proto/atomix/primitive"
 	primitivetype "github.com/atomix/go-client/pkg/client/primitive"
 	"github.com/spf13/cobra"
-	"os"
+	"io"
 	"text/tabwriter"
 )
 
@@ -27,7 +27,7 @@ func newPrimitivesCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "primitives [args]",
 		Short: "List primitives in a partition group",
-		Run:   runPrimitivesCommand,
+		RunE:  withRetry(runPrimitivesCommand),
 	}
 	addClientFlags(cmd)
 	cmd.Flags().StringP("type", "t", "", "the type of primitives to list")
@@ -38,33 +38,56 @@ func newPrimitivesCommand() *cobra.Command {
 	return cmd
 }
 
-func runPrimitivesCommand(cmd *cobra.Command, _ []string) {
-	group := newGroupFromEnv(cmd)
+func runPrimitivesCommand(cmd *cobra.Command, _ []string) error {
+	group, err := newGroupFromEnv(cmd)
+	if err != nil {
+		return err
+	}
 	t, _ := cmd.Flags().GetString("type")
-	var primitives []*primitive.PrimitiveInfo
-	var err error
-	if t == "" {
-		primitives, err = group.GetPrimitives(newTimeoutContext(cmd))
-	} else {
-		primitives, err = group.GetPrimitives(newTimeoutContext(cmd), primitivetype.Type(t))
+	types := []primitivetype.Type{}
+	if t != "" {
+		types = append(types, primitivetype.Type(t))
 	}
 
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	primitives, err := group.GetPrimitives(ctx, types...)
 	if err != nil {
-		ExitWithError(ExitError, err)
+		return err
 	}
+	return printResult(cmd, newPrimitiveList(primitives))
+}
 
-	noHeaders, _ := cmd.Flags().GetBool("no-headers")
-	printPrimitives(primitives, !noHeaders)
+// primitiveInfo is the output representation of a primitive
+type primitiveInfo struct {
+	Name string `json:"name" yaml:"name"`
+	App  string `json:"app" yaml:"app"`
+	Type string `json:"type" yaml:"type"`
+}
+
+// primitiveList is the output representation of a list of primitives
+type primitiveList []*primitiveInfo
+
+func newPrimitiveList(primitives []*primitive.PrimitiveInfo) primitiveList {
+	list := make(primitiveList, len(primitives))
+	for i, info := range primitives {
+		list[i] = &primitiveInfo{
+			Name: info.Name.Name,
+			App:  info.Name.Namespace,
+			Type: info.Type,
+		}
+	}
+	return list
 }
 
-func printPrimitives(primitives []*primitive.PrimitiveInfo, includeHeaders bool) {
+func (l primitiveList) writeTable(out io.Writer, includeHeaders bool) {
 	writer := new(tabwriter.Writer)
-	writer.Init(os.Stdout, 0, 0, 3, ' ', tabwriter.FilterHTML)
+	writer.Init(out, 0, 0, 3, ' ', tabwriter.FilterHTML)
 	if includeHeaders {
 		fmt.Fprintln(writer, "NAME\tAPP\tTYPE")
 	}
-	for _, primitive := range primitives {
-		fmt.Fprintln(writer, fmt.Sprintf("%s\t%s\t%s", primitive.Name.Name, primitive.Name.Namespace, primitive.Type))
+	for _, primitive := range l {
+		fmt.Fprintln(writer, fmt.Sprintf("%s\t%s\t%s", primitive.Name, primitive.App, primitive.Type))
 	}
 	writer.Flush()
 }
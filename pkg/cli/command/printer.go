@@ -0,0 +1,206 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+	"io"
+	"io/ioutil"
+	"k8s.io/client-go/util/jsonpath"
+	"strings"
+	"text/template"
+)
+
+const (
+	outputTable          = "table"
+	outputJSON           = "json"
+	outputYAML           = "yaml"
+	outputGoTemplate     = "go-template"
+	outputGoTemplateFile = "go-template-file"
+	outputJSONPath       = "jsonpath"
+)
+
+// tableWriter is implemented by command results that render themselves as a table
+type tableWriter interface {
+	writeTable(writer io.Writer, includeHeaders bool)
+}
+
+// printer renders command results to a writer
+type printer interface {
+	print(writer io.Writer, result interface{}) error
+}
+
+func addOutputFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringP("output", "o", outputTable, "the output format (table, json, yaml, go-template=..., go-template-file=..., jsonpath=...)")
+}
+
+func getOutputFormat(cmd *cobra.Command) string {
+	format, _ := cmd.Flags().GetString("output")
+	if format == "" {
+		return outputTable
+	}
+	return format
+}
+
+// newPrinter returns a printer for the output format selected by the --output flag
+func newPrinter(cmd *cobra.Command) (printer, error) {
+	format := getOutputFormat(cmd)
+	name, arg := format, ""
+	if i := strings.Index(format, "="); i >= 0 {
+		name, arg = format[:i], format[i+1:]
+	}
+
+	switch name {
+	case outputTable:
+		noHeaders, _ := cmd.Flags().GetBool("no-headers")
+		return &tablePrinter{includeHeaders: !noHeaders}, nil
+	case outputJSON:
+		return &jsonPrinter{}, nil
+	case outputYAML:
+		return &yamlPrinter{}, nil
+	case outputGoTemplate:
+		return newTemplatePrinter(arg)
+	case outputGoTemplateFile:
+		bytes, err := ioutil.ReadFile(arg)
+		if err != nil {
+			return nil, err
+		}
+		return newTemplatePrinter(string(bytes))
+	case outputJSONPath:
+		return newJSONPathPrinter(arg)
+	}
+	return nil, fmt.Errorf("unknown output format %s", format)
+}
+
+// validateOutputFormat checks the output format selected by the --output flag before the command is run
+func validateOutputFormat(cmd *cobra.Command) error {
+	if _, err := newPrinter(cmd); err != nil {
+		return newExitError(ExitInvalidInput, err)
+	}
+	return nil
+}
+
+// printResult writes the given result to the command output
+func printResult(cmd *cobra.Command, result interface{}) error {
+	printer, err := newPrinter(cmd)
+	if err != nil {
+		return newExitError(ExitInvalidInput, err)
+	}
+	return printer.print(cmd.OutOrStdout(), result)
+}
+
+// tablePrinter prints results in a human readable format
+type tablePrinter struct {
+	includeHeaders bool
+}
+
+func (p *tablePrinter) print(writer io.Writer, result interface{}) error {
+	if table, ok := result.(tableWriter); ok {
+		table.writeTable(writer, p.includeHeaders)
+	} else {
+		fmt.Fprintln(writer, result)
+	}
+	return nil
+}
+
+// jsonPrinter prints results as indented JSON
+type jsonPrinter struct{}
+
+func (p *jsonPrinter) print(writer io.Writer, result interface{}) error {
+	bytes, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(writer, string(bytes))
+	return nil
+}
+
+// yamlPrinter prints results as YAML
+type yamlPrinter struct{}
+
+func (p *yamlPrinter) print(writer io.Writer, result interface{}) error {
+	bytes, err := yaml.Marshal(result)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(writer, string(bytes))
+	return nil
+}
+
+// templatePrinter prints results using a Go template
+// The template is executed against the JSON representation of the result, so fields are referenced
+// by the same names used in the JSON and YAML output.
+type templatePrinter struct {
+	template *template.Template
+}
+
+func newTemplatePrinter(text string) (printer, error) {
+	if text == "" {
+		return nil, fmt.Errorf("missing template for output format %s", outputGoTemplate)
+	}
+	t, err := template.New("output").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template %s: %v", text, err)
+	}
+	return &templatePrinter{template: t}, nil
+}
+
+func (p *templatePrinter) print(writer io.Writer, result interface{}) error {
+	data, err := toJSONData(result)
+	if err != nil {
+		return err
+	}
+	return p.template.Execute(writer, data)
+}
+
+// jsonPathPrinter prints results using a JSONPath expression
+type jsonPathPrinter struct {
+	jsonPath *jsonpath.JSONPath
+}
+
+func newJSONPathPrinter(expression string) (printer, error) {
+	if expression == "" {
+		return nil, fmt.Errorf("missing expression for output format %s", outputJSONPath)
+	}
+	jsonPath := jsonpath.New("output").AllowMissingKeys(true)
+	if err := jsonPath.Parse(expression); err != nil {
+		return nil, fmt.Errorf("error parsing jsonpath %s: %v", expression, err)
+	}
+	return &jsonPathPrinter{jsonPath: jsonPath}, nil
+}
+
+func (p *jsonPathPrinter) print(writer io.Writer, result interface{}) error {
+	data, err := toJSONData(result)
+	if err != nil {
+		return err
+	}
+	return p.jsonPath.Execute(writer, data)
+}
+
+// toJSONData converts the given result to generic JSON data
+func toJSONData(result interface{}) (interface{}, error) {
+	bytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	var data interface{}
+	if err := json.Unmarshal(bytes, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strconv"
+	"time"
+)
+
+const (
+	retriesKey = "retries"
+	waitKey    = "wait"
+)
+
+const (
+	initialBackoff = 100 * time.Millisecond
+	maxBackoff     = 2 * time.Second
+)
+
+func addRetryFlags(cmd *cobra.Command) {
+	viper.SetDefault(retriesKey, 3)
+	viper.SetDefault(waitKey, false)
+	cmd.PersistentFlags().Int(retriesKey, viper.GetInt(retriesKey), "the number of times to retry reads when the controller is unavailable")
+	cmd.PersistentFlags().Bool(waitKey, false, "wait until the controller is reachable, bounded by --timeout")
+	bindConfigFlag(retriesKey, cmd.PersistentFlags().Lookup(retriesKey))
+	bindConfigFlag(waitKey, cmd.PersistentFlags().Lookup(waitKey))
+}
+
+// backoff computes exponentially increasing delays between attempts
+type backoff struct {
+	delay time.Duration
+}
+
+func (b *backoff) next() time.Duration {
+	if b.delay == 0 {
+		b.delay = initialBackoff
+	} else if b.delay *= 2; b.delay > maxBackoff {
+		b.delay = maxBackoff
+	}
+	return b.delay
+}
+
+// isRetryable returns whether the given error indicates the controller or partitions could not be reached
+func isRetryable(err error) bool {
+	return getErrorCode(err) == codes.Unavailable
+}
+
+// withRetry wraps an idempotent read command to retry it with exponential backoff while the controller is unavailable
+func withRetry(run func(cmd *cobra.Command, args []string) error) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		retries, _ := strconv.Atoi(getConfig(retriesKey))
+		b := &backoff{}
+		for attempt := 0; ; attempt++ {
+			err := run(cmd, args)
+			if err == nil || attempt >= retries || !isRetryable(err) {
+				return err
+			}
+			time.Sleep(b.next())
+		}
+	}
+}
+
+// waitForController blocks until the controller answers requests or the command timeout expires
+func waitForController(cmd *cobra.Command) error {
+	ctx, cancel := context.WithTimeout(context.Background(), getTimeout(cmd))
+	defer cancel()
+	b := &backoff{}
+	for {
+		err := pingController(ctx)
+		if err == nil {
+			return nil
+		} else if !isRetryable(err) && ctx.Err() == nil {
+			if isControllerResponse(err) {
+				return nil
+			}
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return newExitError(ExitBadConnection, fmt.Errorf("timed out waiting for controller at %s: %s", getClientController(), getErrorMessage(err)))
+		case <-time.After(b.next()):
+		}
+	}
+}
+
+// isControllerResponse returns whether the given error was returned by the controller for an accepted request
+// Errors that did not come from a gRPC round trip, such as credential plugin failures, and requests the
+// controller rejected for their credentials show the controller cannot be used yet.
+func isControllerResponse(err error) bool {
+	st, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+	switch st.Code() {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Canceled, codes.Unauthenticated, codes.PermissionDenied:
+		return false
+	}
+	return true
+}
+
+// pingController makes a request to the controller, returning an error if it could not be reached
+func pingController(ctx context.Context) error {
+	client, err := newClientFromEnv()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	_, err = client.GetGroups(withCredentials(ctx))
+	return err
+}
@@ -0,0 +1,77 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net"
+	"sync/atomic"
+	"testing"
+)
+
+// startTestServer starts a gRPC server that fails all requests with the given status code
+// The returned counter is incremented for each request received by the server.
+func startTestServer(t *testing.T, code codes.Code) (string, *int32) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests := new(int32)
+	server := grpc.NewServer(grpc.UnknownServiceHandler(func(interface{}, grpc.ServerStream) error {
+		atomic.AddInt32(requests, 1)
+		return status.Error(code, "request failed")
+	}))
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	return lis.Addr().String(), requests
+}
+
+func TestWaitForController(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     codes.Code
+		requests int32
+	}{
+		{
+			name:     "unauthenticated",
+			code:     codes.Unauthenticated,
+			requests: 1,
+		},
+		{
+			name:     "permission denied",
+			code:     codes.PermissionDenied,
+			requests: 1,
+		},
+		{
+			name:     "controller answered",
+			code:     codes.NotFound,
+			requests: 2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			address, requests := startTestServer(t, test.code)
+			_, _, err := executeCommand("groups", "--controller", address, "--wait", "--retries", "0", "--timeout", "5s")
+			if code := getErrorCode(err); code != test.code {
+				t.Errorf("expected status %s, got %s (%v)", test.code, code, err)
+			}
+			if count := atomic.LoadInt32(requests); count != test.requests {
+				t.Errorf("expected %d requests, got %d", test.requests, count)
+			}
+		})
+	}
+}
@@ -17,33 +17,72 @@ package command
 import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"io"
+	"os"
 )
 
+var input io.Reader = os.Stdin
+
+var errOutput io.Writer = os.Stderr
+
+// SetInput sets the reader from which commands read interactive input
+func SetInput(reader io.Reader) {
+	input = reader
+}
+
+// SetErrorOutput sets the writer to which commands write errors and diagnostics that are not results
+func SetErrorOutput(writer io.Writer) {
+	errOutput = writer
+}
+
+// GetRootCommand returns the root atomix command
+// Commands write their results to the command output and return errors rather than exiting, so the command
+// tree can be executed in-process. The caller is responsible for mapping errors to exit codes with GetExitCode.
 func GetRootCommand() *cobra.Command {
+	resetState()
 	cmd := &cobra.Command{
 		Use:                    "atomix",
 		Short:                  "Atomix command line client",
 		BashCompletionFunction: bashCompletion,
+		SilenceUsage:           true,
+		SilenceErrors:          true,
+		PersistentPreRunE:      runRootPreRun,
 	}
+	cmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return newExitError(ExitBadArgs, err)
+	})
 
 	viper.SetDefault("controller", ":5679")
 	viper.SetDefault("namespace", "default")
 	viper.SetDefault("app", "default")
+	viper.SetDefault("timeout", "15s")
 
 	cmd.PersistentFlags().String("controller", viper.GetString("controller"), "the controller address")
 	cmd.PersistentFlags().String("namespace", viper.GetString("namespace"), "the partition group namespace")
 	cmd.PersistentFlags().StringP("app", "a", viper.GetString("app"), "the application name")
-	cmd.PersistentFlags().String("config", "", "config file (default: $HOME/.atomix/config.yaml)")
+	cmd.PersistentFlags().StringVar(&configFile, "config", "", "config file (default: $ATOMIX_CONFIG, ./.atomix.yaml or $HOME/.atomix/config.yaml)")
+	addContextFlags(cmd)
+	addTLSFlags(cmd)
+	addCredentialFlags(cmd)
+	addRetryFlags(cmd)
+	addOutputFlags(cmd)
+	addErrorFlags(cmd)
 
-	viper.BindPFlag("controller", cmd.PersistentFlags().Lookup("controller"))
-	viper.BindPFlag("namespace", cmd.PersistentFlags().Lookup("namespace"))
-	viper.BindPFlag("app", cmd.PersistentFlags().Lookup("app"))
+	bindConfigFlag("controller", cmd.PersistentFlags().Lookup("controller"))
+	bindConfigFlag("namespace", cmd.PersistentFlags().Lookup("namespace"))
+	bindConfigFlag("app", cmd.PersistentFlags().Lookup("app"))
 
 	cmd.AddCommand(newCompletionCommand())
 	cmd.AddCommand(newConfigCommand())
+	cmd.AddCommand(newControllerCommand())
+	cmd.AddCommand(newDevCommand())
+	cmd.AddCommand(newExecCommand())
+	cmd.AddCommand(newInitCommand())
+	cmd.AddCommand(newShellCommand())
 	cmd.AddCommand(newGroupCommand())
 	cmd.AddCommand(newGroupsCommand())
 	cmd.AddCommand(newPrimitivesCommand())
+	cmd.AddCommand(newStatusCommand())
 	cmd.AddCommand(newCounterCommand())
 	cmd.AddCommand(newListCommand())
 	cmd.AddCommand(newElectionCommand())
@@ -52,3 +91,35 @@ func GetRootCommand() *cobra.Command {
 	cmd.AddCommand(newSetCommand())
 	return cmd
 }
+
+// resetState clears the configuration and credentials left by commands previously executed in the process
+func resetState() {
+	viper.Reset()
+	configErr = nil
+	configTrusted = false
+	configUpdates = make(map[string]bool)
+	commandFlags = nil
+	credentials = ""
+}
+
+// Close releases the resources held by the commands run in the process, such as TLS tunnels
+func Close() {
+	stopTunnels()
+}
+
+func runRootPreRun(cmd *cobra.Command, _ []string) error {
+	commandFlags = cmd.Flags()
+	if configErr != nil {
+		return newExitError(ExitInvalidInput, configErr)
+	}
+	if err := validateContext(); err != nil {
+		return err
+	}
+	if err := validateOutputFormat(cmd); err != nil {
+		return err
+	}
+	if getConfigBool(waitKey) && cmd.Flags().Lookup("timeout") != nil {
+		return waitForController(cmd)
+	}
+	return nil
+}
@@ -15,7 +15,10 @@
 package command
 
 import (
+	"context"
 	"fmt"
+	"github.com/atomix/go-client/pkg/client"
+	"github.com/atomix/go-client/pkg/client/primitive"
 	"github.com/atomix/go-client/pkg/client/set"
 	"github.com/spf13/cobra"
 )
@@ -41,146 +44,173 @@ func newSetCommand() *cobra.Command {
 	return cmd
 }
 
-func newSetFromName(cmd *cobra.Command) set.Set {
+func newSetFromName(cmd *cobra.Command) (set.Set, error) {
 	name, _ := cmd.Flags().GetString("name")
-	group := newGroupFromName(cmd, name)
-	m, err := group.GetSet(newTimeoutContext(cmd), getPrimitiveName(name))
+	p, err := newPrimitiveFromName(cmd, set.Type, name, func(ctx context.Context, group *client.PartitionGroup, name string) (primitive.Primitive, error) {
+		return group.GetSet(ctx, name)
+	})
 	if err != nil {
-		ExitWithError(ExitError, err)
+		return nil, err
 	}
-	return m
+	return p.(set.Set), nil
 }
 
 func newSetCreateCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:  "create",
 		Args: cobra.NoArgs,
-		Run:  runSetCreateCommand,
+		RunE: runSetCreateCommand,
 	}
 }
 
-func runSetCreateCommand(cmd *cobra.Command, _ []string) {
-	set := newSetFromName(cmd)
-	set.Close()
-	ExitWithOutput(fmt.Sprintf("Created %s", set.Name().String()))
+func runSetCreateCommand(cmd *cobra.Command, _ []string) error {
+	set, err := newSetFromName(cmd)
+	if err != nil {
+		return err
+	}
+	closePrimitive(set)
+	return printResult(cmd, fmt.Sprintf("Created %s", set.Name().String()))
 }
 
 func newSetDeleteCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:  "delete",
 		Args: cobra.NoArgs,
-		Run:  runSetDeleteCommand,
+		RunE: runSetDeleteCommand,
 	}
 }
 
-func runSetDeleteCommand(cmd *cobra.Command, _ []string) {
-	set := newSetFromName(cmd)
-	err := set.Delete()
+func runSetDeleteCommand(cmd *cobra.Command, _ []string) error {
+	set, err := newSetFromName(cmd)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		ExitWithOutput(fmt.Sprintf("Deleted %s", set.Name().String()))
+		return err
+	}
+	if err := deletePrimitive(set); err != nil {
+		return err
 	}
+	return printResult(cmd, fmt.Sprintf("Deleted %s", set.Name().String()))
 }
 
 func newSetAddCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:  "add",
 		Args: cobra.NoArgs,
-		Run:  runSetAddCommand,
+		RunE: runSetAddCommand,
 	}
-	cmd.Flags().StringP("value", "v", "", "the value to add")
-	cmd.MarkFlagRequired("value")
+	addValueFlags(cmd, "the value to add")
 	return cmd
 }
 
-func runSetAddCommand(cmd *cobra.Command, _ []string) {
-	set := newSetFromName(cmd)
-	value, _ := cmd.Flags().GetString("value")
-	added, err := set.Add(newTimeoutContext(cmd), value)
+func runSetAddCommand(cmd *cobra.Command, _ []string) error {
+	value, err := getValue(cmd)
+	if err != nil {
+		return err
+	}
+	set, err := newSetFromName(cmd)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		ExitWithOutput(added)
+		return err
 	}
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	added, err := set.Add(ctx, string(value))
+	if err != nil {
+		return err
+	}
+	return printResult(cmd, added)
 }
 
 func newSetContainsCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:  "contains",
 		Args: cobra.NoArgs,
-		Run:  runSetContainsCommand,
+		RunE: withRetry(runSetContainsCommand),
 	}
-	cmd.Flags().StringP("value", "v", "", "the value to check")
-	cmd.MarkFlagRequired("value")
+	addValueFlags(cmd, "the value to check")
 	return cmd
 }
 
-func runSetContainsCommand(cmd *cobra.Command, _ []string) {
-	set := newSetFromName(cmd)
-	value, _ := cmd.Flags().GetString("value")
-	contains, err := set.Contains(newTimeoutContext(cmd), value)
+func runSetContainsCommand(cmd *cobra.Command, _ []string) error {
+	value, err := getValue(cmd)
+	if err != nil {
+		return err
+	}
+	set, err := newSetFromName(cmd)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		ExitWithOutput(contains)
+		return err
 	}
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	contains, err := set.Contains(ctx, string(value))
+	if err != nil {
+		return err
+	}
+	return printResult(cmd, contains)
 }
 
 func newSetRemoveCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:  "remove",
 		Args: cobra.NoArgs,
-		Run:  runSetRemoveCommand,
+		RunE: runSetRemoveCommand,
 	}
-	cmd.Flags().StringP("value", "v", "", "the value to remove")
-	cmd.MarkFlagRequired("value")
+	addValueFlags(cmd, "the value to remove")
 	return cmd
 }
 
-func runSetRemoveCommand(cmd *cobra.Command, _ []string) {
-	set := newSetFromName(cmd)
-	value, _ := cmd.Flags().GetString("value")
-	removed, err := set.Remove(newTimeoutContext(cmd), value)
+func runSetRemoveCommand(cmd *cobra.Command, _ []string) error {
+	value, err := getValue(cmd)
+	if err != nil {
+		return err
+	}
+	set, err := newSetFromName(cmd)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		ExitWithOutput(removed)
+		return err
 	}
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	removed, err := set.Remove(ctx, string(value))
+	if err != nil {
+		return err
+	}
+	return printResult(cmd, removed)
 }
 
 func newSetSizeCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:  "size",
 		Args: cobra.NoArgs,
-		Run:  runSetSizeCommand,
+		RunE: withRetry(runSetSizeCommand),
 	}
 }
 
-func runSetSizeCommand(cmd *cobra.Command, _ []string) {
-	set := newSetFromName(cmd)
-	size, err := set.Len(newTimeoutContext(cmd))
+func runSetSizeCommand(cmd *cobra.Command, _ []string) error {
+	set, err := newSetFromName(cmd)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	size, err := set.Len(ctx)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		ExitWithOutput(size)
+		return err
 	}
+	return printResult(cmd, size)
 }
 
 func newSetClearCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:  "clear",
 		Args: cobra.NoArgs,
-		Run:  runSetClearCommand,
+		RunE: runSetClearCommand,
 	}
 }
 
-func runSetClearCommand(cmd *cobra.Command, _ []string) {
-	set := newSetFromName(cmd)
-	err := set.Clear(newTimeoutContext(cmd))
+func runSetClearCommand(cmd *cobra.Command, _ []string) error {
+	set, err := newSetFromName(cmd)
 	if err != nil {
-		ExitWithError(ExitError, err)
-	} else {
-		ExitWithSuccess()
+		return err
 	}
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	return set.Clear(ctx)
 }
@@ -0,0 +1,342 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"github.com/atomix/go-client/pkg/client/counter"
+	"github.com/atomix/go-client/pkg/client/election"
+	"github.com/atomix/go-client/pkg/client/list"
+	"github.com/atomix/go-client/pkg/client/lock"
+	"github.com/atomix/go-client/pkg/client/map"
+	primitivetype "github.com/atomix/go-client/pkg/client/primitive"
+	"github.com/atomix/go-client/pkg/client/set"
+	"github.com/mitchellh/go-homedir"
+	"github.com/peterh/liner"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const historyFile = "history"
+
+// primitiveTypes maps primitive commands to the primitive types they operate on
+var primitiveTypes = map[string]primitivetype.Type{
+	"counter":  counter.Type,
+	"election": election.Type,
+	"list":     list.Type,
+	"lock":     lock.Type,
+	"map":      _map.Type,
+	"set":      set.Type,
+}
+
+func newShellCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "shell",
+		Short: "Run commands interactively over a shared connection",
+		Long: `Run commands interactively over a shared connection
+
+Commands are entered without the atomix prefix, e.g. "map get --name users --key alice".
+Partition groups are resolved once and shared by all commands run in the shell.
+
+Shell commands:
+  use <map>   set the map used by map commands that don't specify --name
+  use         clear the map set by use
+  exit        exit the shell`,
+		Args: cobra.NoArgs,
+		RunE: runShellCommand,
+	}
+	return cmd
+}
+
+// shell is an interactive session executing commands from the atomix command tree
+type shell struct {
+	cmd        *cobra.Command
+	line       *liner.State
+	globalArgs []string
+	mapName    string
+	names      map[primitivetype.Type][]string
+}
+
+func runShellCommand(cmd *cobra.Command, _ []string) error {
+	if err := shareClients(); err != nil {
+		return err
+	}
+	defer closeSharedClients()
+
+	s := &shell{
+		cmd:        cmd,
+		line:       liner.NewLiner(),
+		globalArgs: getGlobalArgs(cmd),
+		names:      make(map[primitivetype.Type][]string),
+	}
+	defer s.line.Close()
+	s.line.SetCtrlCAborts(true)
+	s.line.SetWordCompleter(s.complete)
+
+	path, err := getHistoryFile()
+	if err != nil {
+		return err
+	}
+	if file, err := os.Open(path); err == nil {
+		s.line.ReadHistory(file)
+		file.Close()
+	}
+	defer s.writeHistory(path)
+
+	for {
+		input, err := s.line.Prompt(s.prompt())
+		if err == liner.ErrPromptAborted {
+			continue
+		} else if err == io.EOF {
+			fmt.Fprintln(cmd.OutOrStdout())
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		input = strings.TrimSpace(input)
+		if input == "" {
+			continue
+		}
+		s.line.AppendHistory(input)
+
+		args, err := splitCommandLine(input)
+		if err != nil {
+			PrintError(errOutput, newExitError(ExitInvalidInput, err))
+			continue
+		}
+
+		switch args[0] {
+		case "exit", "quit":
+			return nil
+		case "use":
+			s.use(args[1:])
+			continue
+		}
+
+		if err := runCommandLine(cmd.OutOrStdout(), s.globalArgs, s.withDefaultName(args)); err != nil {
+			PrintError(errOutput, err)
+		}
+		commandFlags = cmd.Flags()
+		s.names = make(map[primitivetype.Type][]string)
+	}
+}
+
+func (s *shell) prompt() string {
+	if s.mapName != "" {
+		return fmt.Sprintf("atomix:%s> ", s.mapName)
+	}
+	return "atomix> "
+}
+
+func (s *shell) use(args []string) {
+	switch len(args) {
+	case 0:
+		s.mapName = ""
+	case 1:
+		s.mapName = args[0]
+	default:
+		PrintError(errOutput, newExitError(ExitBadArgs, errors.New("usage: use [map]")))
+	}
+}
+
+// withDefaultName adds the map selected by use to map commands that don't specify a name
+func (s *shell) withDefaultName(args []string) []string {
+	if s.mapName == "" || args[0] != "map" {
+		return args
+	}
+	for _, arg := range args {
+		if arg == "-n" || arg == "--name" || strings.HasPrefix(arg, "--name=") || strings.HasPrefix(arg, "-n=") {
+			return args
+		}
+	}
+	return append(args, "--name", s.mapName)
+}
+
+// writeHistory writes the shell history to the given path
+// The history contains whole command lines, including tokens and values, so it is only readable by the user.
+func (s *shell) writeHistory(path string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	if err := file.Chmod(0600); err != nil {
+		return
+	}
+	s.line.WriteHistory(file)
+}
+
+// complete completes subcommands, flags and primitive names for the word at the cursor
+func (s *shell) complete(line string, pos int) (string, []string, string) {
+	head := line[:pos]
+	tail := line[pos:]
+	start := strings.LastIndex(head, " ") + 1
+	word := head[start:]
+	words := strings.Fields(head[:start])
+
+	var candidates []string
+	switch {
+	case len(words) == 0:
+		candidates = append(getSubcommandNames(s.cmd.Root()), "use", "exit")
+	case words[len(words)-1] == "--name" || words[len(words)-1] == "-n" || (words[0] == "use" && len(words) == 1):
+		t, ok := primitiveTypes[words[0]]
+		if words[0] == "use" {
+			t, ok = _map.Type, true
+		}
+		if ok {
+			candidates = s.getPrimitiveNames(t)
+		}
+	default:
+		target, _, err := s.cmd.Root().Find(words)
+		if err != nil {
+			break
+		}
+		if strings.HasPrefix(word, "-") {
+			target.Flags().VisitAll(func(flag *pflag.Flag) {
+				candidates = append(candidates, "--"+flag.Name)
+			})
+			target.InheritedFlags().VisitAll(func(flag *pflag.Flag) {
+				candidates = append(candidates, "--"+flag.Name)
+			})
+		} else {
+			candidates = getSubcommandNames(target)
+		}
+	}
+
+	completions := []string{}
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, word) {
+			completions = append(completions, candidate+" ")
+		}
+	}
+	sort.Strings(completions)
+	return head[:start], completions, tail
+}
+
+// getPrimitiveNames returns the names of primitives of the given type, caching them until the next command
+func (s *shell) getPrimitiveNames(t primitivetype.Type) []string {
+	if names, ok := s.names[t]; ok {
+		return names
+	}
+	names := []string{}
+	if group, err := newGroupFromEnv(s.cmd); err == nil {
+		ctx, cancel := newTimeoutContext(s.cmd)
+		defer cancel()
+		if primitives, err := group.GetPrimitives(ctx, t); err == nil {
+			for _, primitive := range primitives {
+				names = append(names, primitive.Name.Name)
+			}
+		}
+	}
+	s.names[t] = names
+	return names
+}
+
+func getSubcommandNames(cmd *cobra.Command) []string {
+	names := []string{}
+	for _, child := range cmd.Commands() {
+		if child.IsAvailableCommand() {
+			names = append(names, child.Name())
+		}
+	}
+	return names
+}
+
+// getGlobalArgs returns the root flags set on the given command, to be passed to the commands it runs
+// Flags are parsed by the executing command's merged flag set, so the root flags are checked for
+// changes rather than visited.
+func getGlobalArgs(cmd *cobra.Command) []string {
+	args := []string{}
+	cmd.Root().PersistentFlags().VisitAll(func(flag *pflag.Flag) {
+		if flag.Changed {
+			args = append(args, fmt.Sprintf("--%s=%s", flag.Name, flag.Value.String()))
+		}
+	})
+	return args
+}
+
+func getHistoryFile() (string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".atomix", historyFile), nil
+}
+
+// runCommandLine runs the given arguments with a new command tree, writing results to the given writer
+// The global arguments are passed before the command so flags given on the command line take precedence.
+func runCommandLine(out io.Writer, globalArgs []string, args []string) error {
+	root := GetRootCommand()
+	root.SetOutput(out)
+	root.SetArgs(append(append([]string{}, globalArgs...), args...))
+	return root.Execute()
+}
+
+// splitCommandLine splits a command line into arguments, honoring single quotes, double quotes and escapes
+func splitCommandLine(line string) ([]string, error) {
+	args := []string{}
+	var arg strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, c := range line {
+		switch {
+		case escaped:
+			arg.WriteRune(c)
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inArg = true
+		case c == ' ' || c == '\t':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(c)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %s", line)
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing escape in %s", line)
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"github.com/peterh/liner"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestShellHistoryPermissions(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ".atomix")
+	path := filepath.Join(dir, historyFile)
+
+	s := &shell{line: liner.NewLiner()}
+	defer s.line.Close()
+	s.line.AppendHistory("map put --name test --key foo --value bar --token=secret")
+	s.writeHistory(path)
+
+	for _, test := range []struct {
+		path string
+		mode os.FileMode
+	}{
+		{dir, 0700},
+		{path, 0600},
+	} {
+		info, err := os.Stat(test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mode := info.Mode().Perm(); mode != test.mode {
+			t.Errorf("expected %s to have mode %o, got %o", test.path, test.mode, mode)
+		}
+	}
+
+	// Existing history files are made private when the history is written
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+	s.writeHistory(path)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("expected %s to have mode 600, got %o", path, mode)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "--token=secret") {
+		t.Errorf("expected history to be written, got %q", data)
+	}
+}
+
+func TestNestedExec(t *testing.T) {
+	SetInput(strings.NewReader("exec\n"))
+	defer SetInput(os.Stdin)
+	_, _, err := executeCommand("exec")
+	if code := getTestExitCode(err); code != ExitInvalidInput {
+		t.Errorf("expected exit code %d, got %d (%v)", ExitInvalidInput, code, err)
+	}
+	if sharedPrimitives != nil {
+		t.Error("expected shared primitives to be closed")
+	}
+}
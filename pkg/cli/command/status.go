@@ -0,0 +1,233 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	controllerapi "github.com/atomix/api/proto/atomix/controller"
+	primitiveapi "github.com/atomix/api/proto/atomix/primitive"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+func newStatusCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "status",
+		Aliases: []string{"ping"},
+		Short:   "Check the health of the controller and partition groups",
+		Args:    cobra.NoArgs,
+		RunE:    runStatusCommand,
+	}
+	cmd.Flags().IntP("count", "c", 3, "the number of pings used to measure controller latency")
+	cmd.Flags().Duration("timeout", 15*time.Second, "the operation timeout")
+	cmd.Flags().Bool("no-headers", false, "exclude headers from the output")
+	return cmd
+}
+
+// statusInfo is the output representation of the CLI connection status
+type statusInfo struct {
+	Controller string             `json:"controller" yaml:"controller"`
+	Reachable  bool               `json:"reachable" yaml:"reachable"`
+	Error      string             `json:"error,omitempty" yaml:"error,omitempty"`
+	Latency    *latencyInfo       `json:"latency,omitempty" yaml:"latency,omitempty"`
+	Namespace  string             `json:"namespace" yaml:"namespace"`
+	App        string             `json:"app" yaml:"app"`
+	Partitions []*partitionStatus `json:"partitions" yaml:"partitions"`
+	Healthy    bool               `json:"healthy" yaml:"healthy"`
+}
+
+// latencyInfo is the output representation of the round trip latency to the controller
+type latencyInfo struct {
+	Pings int     `json:"pings" yaml:"pings"`
+	Min   float64 `json:"minMs" yaml:"minMs"`
+	Avg   float64 `json:"avgMs" yaml:"avgMs"`
+	Max   float64 `json:"maxMs" yaml:"maxMs"`
+}
+
+// partitionStatus is the output representation of the status of a partition
+type partitionStatus struct {
+	Group     string `json:"group" yaml:"group"`
+	Partition int    `json:"partition" yaml:"partition"`
+	Address   string `json:"address" yaml:"address"`
+	Reachable bool   `json:"reachable" yaml:"reachable"`
+	Error     string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+func (s *statusInfo) writeTable(out io.Writer, includeHeaders bool) {
+	if s.Reachable {
+		fmt.Fprintln(out, fmt.Sprintf("Controller:  %s (reachable)", s.Controller))
+		fmt.Fprintln(out, fmt.Sprintf("Latency:     min %.3fms, avg %.3fms, max %.3fms (%d pings)", s.Latency.Min, s.Latency.Avg, s.Latency.Max, s.Latency.Pings))
+	} else {
+		fmt.Fprintln(out, fmt.Sprintf("Controller:  %s (unreachable: %s)", s.Controller, s.Error))
+	}
+	fmt.Fprintln(out, fmt.Sprintf("Namespace:   %s", s.Namespace))
+	fmt.Fprintln(out, fmt.Sprintf("App:         %s", s.App))
+	if len(s.Partitions) == 0 {
+		return
+	}
+
+	fmt.Fprintln(out)
+	writer := new(tabwriter.Writer)
+	writer.Init(out, 0, 0, 3, ' ', tabwriter.FilterHTML)
+	if includeHeaders {
+		fmt.Fprintln(writer, "GROUP\tPARTITION\tADDRESS\tSTATUS")
+	}
+	for _, partition := range s.Partitions {
+		status := "OK"
+		if !partition.Reachable {
+			status = fmt.Sprintf("UNREACHABLE: %s", partition.Error)
+		}
+		fmt.Fprintln(writer, fmt.Sprintf("%s\t%d\t%s\t%s", partition.Group, partition.Partition, partition.Address, status))
+	}
+	writer.Flush()
+}
+
+func runStatusCommand(cmd *cobra.Command, _ []string) error {
+	status := &statusInfo{
+		Controller: getClientController(),
+		Namespace:  getClientNamespace(),
+		App:        getClientApp(),
+		Partitions: []*partitionStatus{},
+	}
+
+	groups, err := measureController(cmd, status)
+	if err != nil {
+		status.Error = getErrorMessage(err)
+		if err := printResult(cmd, status); err != nil {
+			return err
+		}
+		return newExitError(ExitBadConnection, fmt.Errorf("controller %s is unreachable", status.Controller))
+	}
+
+	status.Healthy = true
+	for _, group := range groups {
+		for _, partition := range group.Partitions {
+			result := checkPartition(cmd, group.ID.Name, partition)
+			status.Partitions = append(status.Partitions, result)
+			status.Healthy = status.Healthy && result.Reachable
+		}
+	}
+
+	if err := printResult(cmd, status); err != nil {
+		return err
+	}
+	if !status.Healthy {
+		return newExitError(ExitError, errors.New("one or more partitions are unreachable"))
+	}
+	return nil
+}
+
+// measureController measures the latency of partition group requests to the controller
+// The partition groups in the namespace returned by the last request are returned.
+func measureController(cmd *cobra.Command, status *statusInfo) ([]*controllerapi.PartitionGroup, error) {
+	if err := loadCredentials(); err != nil {
+		return nil, err
+	}
+	address, err := getControllerAddress(status.Controller)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpc.Dial(address, grpc.WithInsecure())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	count, _ := cmd.Flags().GetInt("count")
+	if count < 1 {
+		count = 1
+	}
+
+	client := controllerapi.NewControllerServiceClient(conn)
+	request := &controllerapi.GetPartitionGroupsRequest{
+		ID: &controllerapi.PartitionGroupId{
+			Namespace: status.Namespace,
+		},
+	}
+
+	// The connection is established by the first request, so it is made before latency is measured
+	ctx, cancel := newTimeoutContext(cmd)
+	_, err = client.GetPartitionGroups(ctx, request)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []*controllerapi.PartitionGroup
+	latency := &latencyInfo{Pings: count}
+	var total time.Duration
+	for i := 0; i < count; i++ {
+		ctx, cancel := newTimeoutContext(cmd)
+		start := time.Now()
+		response, err := client.GetPartitionGroups(ctx, request)
+		elapsed := time.Since(start)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		groups = response.Groups
+
+		ms := float64(elapsed) / float64(time.Millisecond)
+		if i == 0 || ms < latency.Min {
+			latency.Min = ms
+		}
+		if ms > latency.Max {
+			latency.Max = ms
+		}
+		total += elapsed
+	}
+	latency.Avg = float64(total) / float64(count) / float64(time.Millisecond)
+	status.Reachable = true
+	status.Latency = latency
+	return groups, nil
+}
+
+// checkPartition verifies the primitives in the given partition can be listed
+func checkPartition(cmd *cobra.Command, group string, partition *controllerapi.Partition) *partitionStatus {
+	status := &partitionStatus{
+		Group:     group,
+		Partition: int(partition.PartitionID),
+	}
+	if len(partition.Endpoints) == 0 {
+		status.Error = "no endpoints"
+		return status
+	}
+
+	endpoint := partition.Endpoints[0]
+	status.Address = fmt.Sprintf("%s:%d", endpoint.Host, endpoint.Port)
+	conn, err := grpc.Dial(status.Address, grpc.WithInsecure())
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	defer conn.Close()
+
+	ctx, cancel := newTimeoutContext(cmd)
+	defer cancel()
+	client := primitiveapi.NewPrimitiveServiceClient(conn)
+	request := &primitiveapi.GetPrimitivesRequest{
+		Namespace: getClientApp(),
+	}
+	if _, err := client.GetPrimitives(ctx, request); err != nil {
+		status.Error = getErrorMessage(err)
+		return status
+	}
+	status.Reachable = true
+	return status
+}
@@ -0,0 +1,55 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"testing"
+)
+
+func TestStatus(t *testing.T) {
+	controller := startTestController(t)
+	defer controller.Stop()
+
+	format := "go-template={{.reachable}} {{.healthy}} {{len .partitions}}"
+	tests := []struct {
+		name   string
+		args   []string
+		output string
+		code   int
+	}{
+		{
+			name:   "healthy",
+			args:   []string{"status", "--controller", controller.Address(), "-o", format},
+			output: "true true 3",
+		},
+		{
+			name:   "unreachable",
+			args:   []string{"status", "--controller", "localhost:1", "--timeout", "1s", "-o", format},
+			output: "false false 0",
+			code:   ExitBadConnection,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, _, err := executeCommand(test.args...)
+			if code := getTestExitCode(err); code != test.code {
+				t.Fatalf("expected exit code %d, got %d (%v)", test.code, code, err)
+			}
+			if output != test.output {
+				t.Errorf("expected output %q, got %q", test.output, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/atomix/cli/pkg/tunnel"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"io/ioutil"
+	"strconv"
+)
+
+const (
+	tlsKey                = "tls"
+	tlsCACertKey          = "tls-ca-cert"
+	tlsCertKey            = "tls-cert"
+	tlsKeyKey             = "tls-key"
+	tlsInsecureSkipVerify = "insecure-skip-verify"
+)
+
+// tunnels holds the TLS tunnels opened by the process, keyed by controller address and TLS settings
+var tunnels = make(map[string]*tunnel.Tunnel)
+
+func addTLSFlags(cmd *cobra.Command) {
+	viper.SetDefault(tlsKey, false)
+	viper.SetDefault(tlsInsecureSkipVerify, false)
+	cmd.PersistentFlags().Bool(tlsKey, false, "connect to the controller using TLS")
+	cmd.PersistentFlags().String(tlsCACertKey, "", "the CA certificate used to verify the controller")
+	cmd.PersistentFlags().String(tlsCertKey, "", "the client certificate used for mutual TLS")
+	cmd.PersistentFlags().String(tlsKeyKey, "", "the client key used for mutual TLS")
+	cmd.PersistentFlags().Bool(tlsInsecureSkipVerify, false, "skip verification of the controller certificate")
+	for _, key := range []string{tlsKey, tlsCACertKey, tlsCertKey, tlsKeyKey, tlsInsecureSkipVerify} {
+		bindConfigFlag(key, cmd.PersistentFlags().Lookup(key))
+	}
+}
+
+func getConfigBool(key string) bool {
+	value, _ := strconv.ParseBool(getConfig(key))
+	return value
+}
+
+// getTLSConfig returns the TLS configuration for controller connections, or nil if TLS is not enabled
+// TLS is enabled by the tls setting or implicitly by setting a CA or client certificate.
+func getTLSConfig() (*tls.Config, error) {
+	caCert := getConfig(tlsCACertKey)
+	cert := getConfig(tlsCertKey)
+	key := getConfig(tlsKeyKey)
+	insecure := getConfigBool(tlsInsecureSkipVerify)
+	if !getConfigBool(tlsKey) && caCert == "" && cert == "" && !insecure {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		InsecureSkipVerify: insecure,
+		NextProtos:         []string{"h2"},
+	}
+
+	if caCert != "" {
+		bytes, err := ioutil.ReadFile(caCert)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(bytes) {
+			return nil, newExitError(ExitInvalidInput, fmt.Errorf("no certificates found in %s", caCert))
+		}
+		config.RootCAs = pool
+	}
+
+	if cert != "" || key != "" {
+		if cert == "" || key == "" {
+			return nil, newExitError(ExitInvalidInput, errors.New("both --tls-cert and --tls-key must be set for mutual TLS"))
+		}
+		certificate, err := tls.LoadX509KeyPair(cert, key)
+		if err != nil {
+			return nil, newExitError(ExitInvalidInput, err)
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+	return config, nil
+}
+
+// getControllerAddress returns the address through which to connect to the given controller
+// The go-client always dials without transport security, so when TLS is enabled connections are
+// made through a local tunnel that secures traffic to the controller. The go-client also dials the
+// partitions advertised by the controller directly, so primitive requests are not secured by TLS.
+func getControllerAddress(address string) (string, error) {
+	config, err := getTLSConfig()
+	if err != nil || config == nil {
+		return address, err
+	}
+
+	id := fmt.Sprintf("%s|%s|%s|%s|%t", address, getConfig(tlsCACertKey), getConfig(tlsCertKey), getConfig(tlsKeyKey), config.InsecureSkipVerify)
+	if t, ok := tunnels[id]; ok {
+		return t.Address(), nil
+	}
+	t := tunnel.NewTunnel(address, config)
+	if err := t.Start(); err != nil {
+		return "", err
+	}
+	tunnels[id] = t
+	return t.Address(), nil
+}
+
+// stopTunnels stops the TLS tunnels opened by the process
+func stopTunnels() {
+	for id, t := range tunnels {
+		t.Stop()
+		delete(tunnels, id)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/spf13/cobra"
+	"io/ioutil"
+	"strings"
+)
+
+const (
+	encodingUTF8   = "utf8"
+	encodingBase64 = "base64"
+	encodingHex    = "hex"
+)
+
+// addValueFlags adds the flags used to read a value from the command line, a file or stdin
+func addValueFlags(cmd *cobra.Command, usage string) {
+	cmd.Flags().StringP("value", "v", "", usage)
+	cmd.Flags().String("value-file", "", "a file from which to read the value")
+	cmd.Flags().Bool("stdin", false, "read the value from stdin")
+	addEncodingFlag(cmd)
+}
+
+// addEncodingFlag adds the --encoding flag used to decode values read by and encode values written by the command
+func addEncodingFlag(cmd *cobra.Command) {
+	cmd.Flags().String("encoding", encodingUTF8, "the value encoding (utf8, base64, hex)")
+}
+
+// addRawFlag adds the --raw flag used to write the bytes of a value to the output
+func addRawFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("raw", false, "write the value bytes to the output without formatting or encoding")
+}
+
+// getEncoding returns the value encoding set by the --encoding flag
+func getEncoding(cmd *cobra.Command) (string, error) {
+	encoding, err := cmd.Flags().GetString("encoding")
+	if err != nil {
+		return encodingUTF8, nil
+	}
+	switch encoding {
+	case encodingUTF8, encodingBase64, encodingHex:
+		return encoding, nil
+	}
+	return "", newExitError(ExitInvalidInput, fmt.Errorf("invalid encoding %s", encoding))
+}
+
+// getValue returns the decoded value set by --value, --value-file or --stdin
+func getValue(cmd *cobra.Command) ([]byte, error) {
+	encoding, err := getEncoding(cmd)
+	if err != nil {
+		return nil, err
+	}
+	file, _ := cmd.Flags().GetString("value-file")
+	stdin, _ := cmd.Flags().GetBool("stdin")
+
+	sources := 0
+	for _, set := range []bool{cmd.Flags().Changed("value"), file != "", stdin} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return nil, newExitError(ExitInvalidInput, fmt.Errorf("exactly one of --value, --value-file or --stdin is required"))
+	}
+
+	var value []byte
+	switch {
+	case file != "":
+		value, err = ioutil.ReadFile(file)
+	case stdin:
+		value, err = ioutil.ReadAll(input)
+	default:
+		s, _ := cmd.Flags().GetString("value")
+		value = []byte(s)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeValue(value, encoding)
+}
+
+// decodeValue decodes a value in the given encoding
+func decodeValue(value []byte, encoding string) ([]byte, error) {
+	var decoded []byte
+	var err error
+	switch encoding {
+	case encodingBase64:
+		decoded, err = base64.StdEncoding.DecodeString(strings.TrimSpace(string(value)))
+	case encodingHex:
+		decoded, err = hex.DecodeString(strings.TrimSpace(string(value)))
+	default:
+		return value, nil
+	}
+	if err != nil {
+		return nil, newExitError(ExitInvalidInput, fmt.Errorf("invalid %s value: %s", encoding, err))
+	}
+	return decoded, nil
+}
+
+// encodeValue encodes a value in the given encoding
+func encodeValue(value []byte, encoding string) string {
+	switch encoding {
+	case encodingBase64:
+		return base64.StdEncoding.EncodeToString(value)
+	case encodingHex:
+		return hex.EncodeToString(value)
+	}
+	return string(value)
+}
+
+// printValue prints the given result, or the raw bytes of the value if --raw is set
+func printValue(cmd *cobra.Command, value []byte, result interface{}) error {
+	if raw, _ := cmd.Flags().GetBool("raw"); raw {
+		_, err := cmd.OutOrStdout().Write(value)
+		return err
+	}
+	return printResult(cmd, result)
+}
@@ -0,0 +1,88 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dev
+
+import (
+	"context"
+	"fmt"
+	"github.com/atomix/api/proto/atomix/protocols/raft"
+	"github.com/atomix/go-client/pkg/client"
+	"github.com/atomix/go-framework/pkg/atomix/registry"
+	"github.com/atomix/go-local/pkg/atomix/local"
+	"net"
+)
+
+// NewController returns a new in-process controller listening on the given port
+// If the port is 0, a free port is chosen when the controller is started.
+func NewController(port int) *Controller {
+	return &Controller{
+		port: port,
+	}
+}
+
+// Controller is an in-process Atomix controller
+// The controller implements the controller service and starts in-memory partitions implementing the
+// primitive services for each partition group created through it. It's intended for testing and local
+// development, and all state is lost when the controller is stopped.
+type Controller struct {
+	port       int
+	controller *local.Controller
+}
+
+// Start starts the controller
+func (c *Controller) Start() error {
+	if c.port == 0 {
+		port, err := getFreePort()
+		if err != nil {
+			return err
+		}
+		c.port = port
+	}
+	c.controller = local.NewController(c.port, registry.Registry)
+	return c.controller.Start()
+}
+
+// Address returns the address of the controller
+func (c *Controller) Address() string {
+	return fmt.Sprintf("localhost:%d", c.port)
+}
+
+// CreateGroup creates a partition group in the given namespace
+func (c *Controller) CreateGroup(ctx context.Context, namespace string, name string, partitions int, partitionSize int) error {
+	cli, err := client.NewClient(c.Address(), client.WithNamespace(namespace))
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+	_, err = cli.CreateGroup(ctx, name, partitions, partitionSize, &raft.RaftProtocol{})
+	return err
+}
+
+// Stop stops the controller and all its partitions
+func (c *Controller) Stop() {
+	if c.controller != nil {
+		c.controller.Stop()
+	}
+}
+
+// getFreePort returns a port that's available for listening
+func getFreePort() (int, error) {
+	lis, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return 0, err
+	}
+	defer lis.Close()
+	return lis.Addr().(*net.TCPAddr).Port, nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dev
+
+import (
+	"context"
+	"github.com/atomix/go-client/pkg/client"
+	"testing"
+	"time"
+)
+
+func TestController(t *testing.T) {
+	controller := NewController(0)
+	if err := controller.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer controller.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := controller.CreateGroup(ctx, "default", "test", 3, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := client.NewClient(controller.Address(), client.WithNamespace("default"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	group, err := c.GetGroup(ctx, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if group.Partitions != 3 {
+		t.Errorf("expected 3 partitions, got %d", group.Partitions)
+	}
+
+	m, err := group.GetMap(ctx, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if _, err := m.Put(ctx, "foo", []byte("bar")); err != nil {
+		t.Fatal(err)
+	}
+	kv, err := m.Get(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kv == nil || string(kv.Value) != "bar" {
+		t.Errorf("expected value bar, got %v", kv)
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tunnel
+
+import (
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+)
+
+const socketName = "tunnel.sock"
+
+// NewTunnel returns a new tunnel forwarding local connections to the TLS endpoint at the given address
+// If the configuration does not name the server, the server certificate is verified against the host of the
+// address, or localhost if the address has no host.
+func NewTunnel(address string, config *tls.Config) *Tunnel {
+	if config.ServerName == "" {
+		config = config.Clone()
+		config.ServerName = getServerName(address)
+	}
+	return &Tunnel{
+		address: address,
+		config:  config,
+	}
+}
+
+// getServerName returns the host of the given address, defaulting to localhost
+func getServerName(address string) string {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	if host == "" {
+		return "localhost"
+	}
+	return host
+}
+
+// Tunnel accepts plaintext connections on a Unix socket and forwards them to a remote TLS endpoint
+// The tunnel allows clients that can only dial without transport security to connect to servers
+// that require TLS or mutual TLS. The socket is created in a directory only accessible by the current
+// user, so other local users cannot use the tunnel or the client certificate it presents.
+type Tunnel struct {
+	address  string
+	config   *tls.Config
+	dir      string
+	listener net.Listener
+}
+
+// Start verifies the remote endpoint can be reached and starts accepting local connections
+func (t *Tunnel) Start() error {
+	conn, err := tls.Dial("tcp", t.address, t.config)
+	if err != nil {
+		return err
+	}
+	conn.Close()
+
+	dir, err := ioutil.TempDir("", "atomix-tunnel-")
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(dir, 0700); err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+	listener, err := net.Listen("unix", filepath.Join(dir, socketName))
+	if err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+	t.dir = dir
+	t.listener = listener
+	go t.accept()
+	return nil
+}
+
+// Address returns the local address to which clients should connect, in the unix:path form accepted by gRPC
+func (t *Tunnel) Address() string {
+	return "unix:" + t.listener.Addr().String()
+}
+
+func (t *Tunnel) accept() {
+	for {
+		conn, err := t.listener.Accept()
+		if err != nil {
+			return
+		}
+		go t.forward(conn)
+	}
+}
+
+func (t *Tunnel) forward(conn net.Conn) {
+	defer conn.Close()
+	remote, err := tls.Dial("tcp", t.address, t.config)
+	if err != nil {
+		return
+	}
+	defer remote.Close()
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(remote, conn)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(conn, remote)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+// Stop stops accepting local connections and removes the socket
+func (t *Tunnel) Stop() error {
+	var err error
+	if t.listener != nil {
+		err = t.listener.Close()
+	}
+	if t.dir != "" {
+		os.RemoveAll(t.dir)
+	}
+	return err
+}
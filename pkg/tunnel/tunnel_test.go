@@ -0,0 +1,236 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tunnel
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCerts holds a CA and the server and client certificates it issued
+type testCerts struct {
+	pool   *x509.CertPool
+	server tls.Certificate
+	client tls.Certificate
+}
+
+func newTestCerts(t *testing.T) *testCerts {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	issue := func(serial int64, usage x509.ExtKeyUsage) tls.Certificate {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "localhost"},
+			DNSNames:     []string{"localhost"},
+			IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	}
+	return &testCerts{
+		pool:   pool,
+		server: issue(2, x509.ExtKeyUsageServerAuth),
+		client: issue(3, x509.ExtKeyUsageClientAuth),
+	}
+}
+
+// startEchoServer starts a mutual TLS server that echoes lines back to the client
+func startEchoServer(t *testing.T, certs *testCerts) net.Listener {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{certs.server},
+		ClientCAs:    certs.pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					conn.Write([]byte(line))
+				}
+			}()
+		}
+	}()
+	return listener
+}
+
+func TestTunnel(t *testing.T) {
+	certs := newTestCerts(t)
+	server := startEchoServer(t, certs)
+	defer server.Close()
+
+	tunnel := NewTunnel(server.Addr().String(), &tls.Config{
+		RootCAs:      certs.pool,
+		Certificates: []tls.Certificate{certs.client},
+	})
+	if err := tunnel.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	address := tunnel.Address()
+	if !strings.HasPrefix(address, "unix:") {
+		t.Fatalf("expected a unix address, got %s", address)
+	}
+	path := strings.TrimPrefix(address, "unix:")
+	info, err := os.Stat(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0700 {
+		t.Errorf("expected tunnel directory mode 0700, got %o", info.Mode().Perm())
+	}
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "hello\n" {
+		t.Errorf("expected hello, got %q", line)
+	}
+
+	if err := tunnel.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Dir(path)); !os.IsNotExist(err) {
+		t.Errorf("expected tunnel directory to be removed")
+	}
+}
+
+func TestTunnelDefaultHost(t *testing.T) {
+	certs := newTestCerts(t)
+	server := startEchoServer(t, certs)
+	defer server.Close()
+
+	// An address without a host, such as the default controller address, is verified as localhost
+	_, port, err := net.SplitHostPort(server.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tunnel := NewTunnel(":"+port, &tls.Config{
+		RootCAs:      certs.pool,
+		Certificates: []tls.Certificate{certs.client},
+	})
+	if err := tunnel.Start(); err != nil {
+		t.Fatal(err)
+	}
+	tunnel.Stop()
+}
+
+func TestTunnelVerifiesServer(t *testing.T) {
+	certs := newTestCerts(t)
+	server := startEchoServer(t, certs)
+	defer server.Close()
+
+	// A pool that does not contain the CA fails verification of the server certificate
+	tunnel := NewTunnel(server.Addr().String(), &tls.Config{
+		RootCAs:      x509.NewCertPool(),
+		Certificates: []tls.Certificate{certs.client},
+	})
+	if err := tunnel.Start(); err == nil {
+		tunnel.Stop()
+		t.Fatal("expected the tunnel to fail to verify the server")
+	}
+}
+
+func TestTunnelRequiresClientCertificate(t *testing.T) {
+	certs := newTestCerts(t)
+	server := startEchoServer(t, certs)
+	defer server.Close()
+
+	tunnel := NewTunnel(server.Addr().String(), &tls.Config{
+		RootCAs: certs.pool,
+	})
+	if err := tunnel.Start(); err != nil {
+		// The server may reject the handshake before the tunnel starts
+		return
+	}
+	defer tunnel.Stop()
+
+	conn, err := net.Dial("unix", strings.TrimPrefix(tunnel.Address(), "unix:"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("hello\n"))
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := bufio.NewReader(conn).ReadString('\n'); err == nil {
+		t.Fatal("expected the server to reject a client without a certificate")
+	}
+}